@@ -0,0 +1,59 @@
+package logtide
+
+import (
+	"fmt"
+)
+
+// AuthFailurePolicy controls what happens to new logs while shipping is
+// paused because the ingest API rejected the API key.
+type AuthFailurePolicy int
+
+const (
+	// AuthFailureBuffer keeps accepting logs into the buffer, up to
+	// MaxQueueSize, so they are sent once SetAPIKey resumes shipping.
+	AuthFailureBuffer AuthFailurePolicy = iota
+
+	// AuthFailureDrop rejects new logs with ErrInvalidAPIKey until SetAPIKey
+	// resumes shipping.
+	AuthFailureDrop
+)
+
+// authFailure pauses background shipping on b the first time the API key is
+// rejected, reporting err to the error handler and calling OnAuthFailure.
+// Later rejections while paused do nothing.
+func (c *Client) authFailure(b *Batcher, err error) {
+	if !c.authFailed.CompareAndSwap(false, true) {
+		return
+	}
+
+	b.Pause()
+	c.handleError(fmt.Errorf("API key rejected, log shipping paused: %w", err))
+	if c.config.OnAuthFailure != nil {
+		c.config.OnAuthFailure()
+	}
+}
+
+// SetAPIKey replaces the API key sent to the ingest API, for example with a
+// rotated key fetched from OnAuthFailure. If shipping was paused because the
+// previous key was rejected, it resumes, unless Pause was called, and the
+// logs buffered meanwhile are flushed. An empty key is rejected with
+// ErrInvalidAPIKey.
+func (c *Client) SetAPIKey(key string) error {
+	if key == "" {
+		return ErrInvalidAPIKey
+	}
+
+	// Under keyMu rather than c.mu, so rotating the key never waits on other
+	// client operations such as a log call blocked on the paused queue
+	c.keyMu.Lock()
+	c.config.APIKey = key
+	c.httpClient.SetAPIKey(key)
+	c.keyMu.Unlock()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.authFailed.CompareAndSwap(true, false) && !c.paused.Load() {
+		c.batcher.Resume()
+	}
+	return nil
+}
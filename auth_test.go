@@ -0,0 +1,236 @@
+package logtide
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newAuthServer returns a server that accepts only requests with the API key
+// in *valid, and counts the requests it rejects.
+func newAuthServer(t *testing.T, valid *atomic.Value, rejected *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != valid.Load().(string) {
+			rejected.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(IngestResponse{Received: len(req.Logs)})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientAuthFailurePausesShipping(t *testing.T) {
+	var valid atomic.Value
+	valid.Store("lp_new_key")
+	var rejected atomic.Int32
+	server := newAuthServer(t, &valid, &rejected)
+
+	var failures atomic.Int32
+	var dead atomic.Int32
+	var handled atomic.Int32
+	client, err := New(
+		WithAPIKey("lp_revoked_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithBatchSize(1),
+		WithFlushInterval(10*time.Millisecond),
+		WithOnAuthFailure(func() { failures.Add(1) }),
+		WithDeadLetter(func(logs []Log, err error) { dead.Add(int32(len(logs))) }),
+		WithErrorHandler(func(err error) {
+			if errors.Is(err, ErrInvalidAPIKey) {
+				handled.Add(1)
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "rejected", nil)
+	if err := client.Flush(ctx); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("Flush() error = %v, want ErrInvalidAPIKey", err)
+	}
+
+	// Later logs stay buffered instead of failing batch after batch
+	for i := 0; i < 5; i++ {
+		if err := client.Info(ctx, "buffered", nil); err != nil {
+			t.Fatalf("Info() while paused error = %v", err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if got := rejected.Load(); got != 1 {
+		t.Errorf("server rejected %d requests, want 1", got)
+	}
+	if got := failures.Load(); got != 1 {
+		t.Errorf("OnAuthFailure called %d times, want 1", got)
+	}
+	if got := dead.Load(); got != 1 {
+		t.Errorf("dead-lettered %d logs, want the rejected one", got)
+	}
+	if got := handled.Load(); got == 0 {
+		t.Error("error handler was not told about the rejected key")
+	}
+	if n := client.batcher.Size(); n != 5 {
+		t.Errorf("buffered logs while paused = %d, want 5", n)
+	}
+
+	// A valid key resumes shipping and sends the buffered logs
+	if err := client.SetAPIKey("lp_new_key"); err != nil {
+		t.Fatalf("SetAPIKey() error = %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for client.batcher.Size() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d logs still buffered after SetAPIKey()", client.batcher.Size())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := client.FlushAndWait(ctx); err != nil {
+		t.Errorf("FlushAndWait() after SetAPIKey() error = %v", err)
+	}
+	if got := rejected.Load(); got != 1 {
+		t.Errorf("server rejected %d requests after SetAPIKey(), want still 1", got)
+	}
+}
+
+func TestClientAuthFailureDrop(t *testing.T) {
+	var valid atomic.Value
+	valid.Store("lp_new_key")
+	var rejected atomic.Int32
+	server := newAuthServer(t, &valid, &rejected)
+
+	client, err := New(
+		WithAPIKey("lp_revoked_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithAuthFailurePolicy(AuthFailureDrop),
+		WithErrorHandler(func(error) {}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "rejected", nil)
+	client.Flush(ctx)
+
+	if err := client.Info(ctx, "dropped", nil); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Info() while paused error = %v, want ErrInvalidAPIKey", err)
+	}
+
+	if err := client.SetAPIKey("lp_new_key"); err != nil {
+		t.Fatalf("SetAPIKey() error = %v", err)
+	}
+	if err := client.Info(ctx, "accepted", nil); err != nil {
+		t.Errorf("Info() after SetAPIKey() error = %v", err)
+	}
+	if err := client.SetAPIKey(""); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("SetAPIKey(\"\") error = %v, want ErrInvalidAPIKey", err)
+	}
+}
+
+func TestClientSetAPIKeyKeepsPause(t *testing.T) {
+	var valid atomic.Value
+	valid.Store("lp_new_key")
+	var rejected atomic.Int32
+	server := newAuthServer(t, &valid, &rejected)
+
+	client, err := New(
+		WithAPIKey("lp_revoked_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(10*time.Millisecond),
+		WithErrorHandler(func(error) {}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "rejected", nil)
+	client.Flush(ctx)
+
+	// Neither a new key nor Resume alone resumes shipping
+	client.Pause()
+	client.SetAPIKey("lp_new_key")
+	client.Info(ctx, "buffered", nil)
+	time.Sleep(50 * time.Millisecond)
+	if n := client.batcher.Size(); n != 1 {
+		t.Fatalf("buffered logs after SetAPIKey() while paused = %d, want 1", n)
+	}
+
+	client.Resume()
+	deadline := time.Now().Add(time.Second)
+	for client.batcher.Size() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("log still buffered after Resume()")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClientSetAPIKeyWithBlockedLogCall(t *testing.T) {
+	var valid atomic.Value
+	valid.Store("lp_new_key")
+	var rejected atomic.Int32
+	server := newAuthServer(t, &valid, &rejected)
+
+	client, err := New(
+		WithAPIKey("lp_revoked_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(10*time.Millisecond),
+		WithMaxQueueSize(2),
+		WithBackpressure(BackpressureBlock),
+		WithErrorHandler(func(error) {}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "rejected", nil)
+	client.Flush(ctx)
+
+	// Shipping is paused, so the third log call blocks on the full queue
+	client.Info(ctx, "first", nil)
+	client.Info(ctx, "second", nil)
+	blocked := make(chan error, 1)
+	go func() { blocked <- client.Info(ctx, "third", nil) }()
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() { done <- client.SetAPIKey("lp_new_key") }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("SetAPIKey() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SetAPIKey() did not return while a log call was blocked")
+	}
+
+	select {
+	case err := <-blocked:
+		if err != nil {
+			t.Errorf("blocked Info() error = %v after SetAPIKey()", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked Info() did not return after SetAPIKey()")
+	}
+}
@@ -2,6 +2,10 @@ package logtide
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
 	"sync"
 	"time"
 )
@@ -9,19 +13,75 @@ import (
 // FlushFunc is a function that flushes a batch of logs.
 type FlushFunc func(ctx context.Context, logs []Log) error
 
+// BackpressurePolicy controls what Add does when the queue is full.
+type BackpressurePolicy int
+
+const (
+	// BackpressureDrop rejects new logs with ErrQueueFull while the queue is full.
+	BackpressureDrop BackpressurePolicy = iota
+
+	// BackpressureBlock makes Add wait until a flush frees space or its context is done.
+	BackpressureBlock
+)
+
 // Batcher handles automatic batching of logs with size and time-based flushing.
 type Batcher struct {
-	mu          sync.Mutex
-	logs        []Log
-	maxSize     int
+	mu            sync.Mutex
+	logs          []Log
+	maxSize       int
 	flushInterval time.Duration
-	flushFunc   FlushFunc
-
-	ctx        context.Context
-	cancel     context.CancelFunc
-	wg         sync.WaitGroup
-	flushChan  chan struct{}
-	stopped    bool
+	flushFunc     FlushFunc
+	errorHandler  func(error)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// deliverCtx bounds background deliveries. It is cancelled when the stop
+	// context is done before they finish, and the logs of the deliveries it
+	// cuts short are collected in abandoned.
+	deliverCtx    context.Context
+	deliverCancel context.CancelFunc
+	abandoned     []Log
+
+	flushChan chan struct{}
+	resetChan chan struct{} // signals the background flusher to reset its ticker
+	stopped   bool
+
+	highWaterMark  float64  // fraction of maxSize that triggers an early flush
+	flushThreshold int      // buffered logs that trigger an early flush
+	flushLevel     LogLevel // logs at or above this level trigger an immediate flush
+
+	maxQueueSize int
+	backpressure BackpressurePolicy
+	spaceChan    chan struct{} // closed and replaced whenever buffered logs are taken
+
+	inFlight int           // batches taken from the buffer but not yet delivered
+	idleChan chan struct{} // closed and replaced whenever inFlight drops to zero
+
+	work    chan []Log // background batches for flush workers; nil without workers
+	workers sync.WaitGroup
+
+	sendSlots chan struct{} // one token per running flush; nil means unlimited
+
+	stopTimeout    time.Duration // bounds the final flush of Stop
+	prioritize     bool          // flush higher-severity logs first
+	groupByService bool          // never mix services in one batch
+	traceAffinity  bool          // keep logs of a trace in one batch where they fit
+	timeWindow     time.Duration // flush at multiples of this and never mix windows; zero means interval flushing
+	flushTimeout   time.Duration // bounds each background delivery; zero means no limit
+
+	ringSize  int   // fixed buffer capacity in ring-buffer mode; zero otherwise
+	ringStart int   // index of the oldest log once the ring is full
+	dropped   int64 // logs evicted from the ring
+
+	lastFlush      time.Time // end of the last successful delivery
+	failures       int       // deliveries failed since the last success
+	unhealthyAfter int       // failures that make the batcher unhealthy; zero means never
+	flusherExited  bool      // the background flusher died from a panic
+
+	paused      bool // background flushes are suspended
+	manualFlush bool // no background flusher; Add flushes synchronously at the threshold
 }
 
 // BatcherConfig holds the configuration for a batcher.
@@ -29,6 +89,71 @@ type BatcherConfig struct {
 	MaxSize       int
 	FlushInterval time.Duration
 	FlushFunc     FlushFunc
+
+	// ErrorHandler is called with errors from background flushes (optional).
+	ErrorHandler func(error)
+
+	// HighWaterMark is the fraction of MaxSize (0, 1] at which a flush is
+	// triggered early. Zero means flush only when MaxSize is reached.
+	HighWaterMark float64
+
+	// FlushLevel makes logs at or above this level trigger an immediate flush.
+	// Empty means level does not affect flushing.
+	FlushLevel LogLevel
+
+	// MaxQueueSize bounds the number of buffered logs. Zero means unbounded.
+	MaxQueueSize int
+
+	// Backpressure selects the behavior of Add once MaxQueueSize is reached.
+	Backpressure BackpressurePolicy
+
+	// FlushConcurrency is the number of workers delivering background flushes
+	// in parallel. Zero or one delivers them one at a time.
+	FlushConcurrency int
+
+	// MaxInFlight bounds how many calls to FlushFunc run at once, across
+	// background and manual flushes. Zero means unlimited.
+	MaxInFlight int
+
+	// FlushTimeout bounds each background delivery so a hung send cannot stall
+	// the flusher. Zero means no limit.
+	FlushTimeout time.Duration
+
+	// StopTimeout bounds the final flush of Stop. Zero means 10 seconds.
+	StopTimeout time.Duration
+
+	// Prioritize orders each flush by severity, most severe first, keeping
+	// logs of the same level in the order they were added.
+	Prioritize bool
+
+	// GroupByService splits each flush into separate batches per service, so
+	// every batch holds logs of a single service.
+	GroupByService bool
+
+	// TraceAffinity orders each flush so that logs sharing a TraceID are
+	// adjacent, keeping traces in the order of their first log, and starts a
+	// new batch rather than split a trace that fits in one.
+	TraceAffinity bool
+
+	// TimeWindow, if positive, replaces FlushInterval with flushes at every
+	// wall-clock multiple of TimeWindow, and splits each flush into separate
+	// batches per window of log time.
+	TimeWindow time.Duration
+
+	// RingBuffer, if positive, buffers at most this many logs and evicts the
+	// oldest when full, so Add never blocks or fails. It overrides MaxQueueSize
+	// and Backpressure.
+	RingBuffer int
+
+	// UnhealthyAfter is the number of consecutive failed deliveries after
+	// which Healthy reports false. Zero means failures never do.
+	UnhealthyAfter int
+
+	// ManualFlush starts no goroutines: logs are only sent by Flush, FlushN,
+	// and Stop, or synchronously by the Add that reaches the flush threshold
+	// (or fills the queue). FlushInterval, TimeWindow flush times, and
+	// FlushConcurrency are ignored, and Resume does not flush.
+	ManualFlush bool
 }
 
 // DefaultBatcherConfig returns the default batcher configuration.
@@ -54,17 +179,60 @@ func NewBatcher(config *BatcherConfig) *Batcher {
 	if config.FlushInterval <= 0 {
 		config.FlushInterval = 5 * time.Second
 	}
+	if config.StopTimeout <= 0 {
+		config.StopTimeout = defaultStopTimeout
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
+	deliverCtx, deliverCancel := context.WithCancel(context.Background())
 
 	b := &Batcher{
-		logs:          make([]Log, 0, config.MaxSize),
-		maxSize:       config.MaxSize,
-		flushInterval: config.FlushInterval,
-		flushFunc:     config.FlushFunc,
-		ctx:           ctx,
-		cancel:        cancel,
-		flushChan:     make(chan struct{}, 1),
+		logs:           make([]Log, 0, config.MaxSize),
+		maxSize:        config.MaxSize,
+		flushInterval:  config.FlushInterval,
+		flushFunc:      config.FlushFunc,
+		errorHandler:   config.ErrorHandler,
+		ctx:            ctx,
+		cancel:         cancel,
+		deliverCtx:     deliverCtx,
+		deliverCancel:  deliverCancel,
+		flushChan:      make(chan struct{}, 1),
+		resetChan:      make(chan struct{}, 1),
+		highWaterMark:  config.HighWaterMark,
+		flushThreshold: flushThresholdFor(config.MaxSize, config.HighWaterMark),
+		flushLevel:     config.FlushLevel,
+		maxQueueSize:   config.MaxQueueSize,
+		backpressure:   config.Backpressure,
+		spaceChan:      make(chan struct{}),
+		idleChan:       make(chan struct{}),
+		stopTimeout:    config.StopTimeout,
+		prioritize:     config.Prioritize,
+		groupByService: config.GroupByService,
+		traceAffinity:  config.TraceAffinity,
+		timeWindow:     config.TimeWindow,
+		flushTimeout:   config.FlushTimeout,
+		ringSize:       config.RingBuffer,
+		unhealthyAfter: config.UnhealthyAfter,
+		manualFlush:    config.ManualFlush,
+	}
+	if b.ringSize > 0 {
+		b.logs = make([]Log, 0, b.ringSize)
+	}
+	if config.MaxInFlight > 0 {
+		b.sendSlots = make(chan struct{}, config.MaxInFlight)
+	}
+
+	if b.manualFlush {
+		return b
+	}
+
+	// Start flush workers
+	if config.FlushConcurrency > 1 {
+		b.work = make(chan []Log)
+		b.workers.Add(config.FlushConcurrency)
+		for i := 0; i < config.FlushConcurrency; i++ {
+			go b.flushWorker()
+		}
 	}
 
 	// Start background flusher
@@ -74,104 +242,680 @@ func NewBatcher(config *BatcherConfig) *Batcher {
 	return b
 }
 
-// Add adds a log to the batch. If the batch size reaches maxSize, it triggers a flush.
+// Add adds a log to the batch. If the batch size reaches maxSize (or the
+// configured high-water mark), it triggers a flush.
 func (b *Batcher) Add(log Log) error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	return b.AddContext(context.Background(), log)
+}
 
-	if b.stopped {
-		return ErrClientClosed
+// AddContext adds a log to the batch like Add. A context that is already done
+// is rejected with ctx.Err() without enqueuing. When the queue is full and the
+// backpressure policy is BackpressureBlock, it waits for space and returns
+// ctx.Err() if the context is done first.
+func (b *Batcher) AddContext(ctx context.Context, log Log) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	// Add log to batch
-	b.logs = append(b.logs, log)
+	for {
+		b.mu.Lock()
+
+		if b.stopped {
+			b.mu.Unlock()
+			return ErrClientClosed
+		}
+
+		log.enqueued = time.Now()
+
+		if b.ringSize > 0 && len(b.logs) >= b.ringSize {
+			// Overwrite the oldest log
+			releaseScopes(b.logs[b.ringStart : b.ringStart+1])
+			if log.scope != nil {
+				log.scope.add()
+			}
+			b.logs[b.ringStart] = log
+			b.ringStart = (b.ringStart + 1) % b.ringSize
+			b.dropped++
+
+			b.mu.Unlock()
+			return nil
+		}
+
+		if b.ringSize > 0 || b.maxQueueSize <= 0 || len(b.logs) < b.maxQueueSize {
+			// Add log to batch
+			if log.scope != nil {
+				log.scope.add()
+			}
+			b.logs = append(b.logs, log)
+
+			// Check if we need to flush based on size or level
+			flush := len(b.logs) >= b.flushThreshold || b.flushesImmediately(log.Level)
+			if flush && !b.manualFlush {
+				b.triggerFlush()
+			}
+
+			b.mu.Unlock()
+			if flush && b.manualFlush {
+				b.flushBackground()
+			}
+			return nil
+		}
+
+		// Queue is full: make sure a flush is on its way
+		if b.manualFlush && !b.paused {
+			b.mu.Unlock()
+			b.flushBackground()
+			continue
+		}
+		b.triggerFlush()
+
+		if b.backpressure != BackpressureBlock {
+			b.mu.Unlock()
+			return ErrQueueFull
+		}
+
+		space := b.spaceChan
+		b.mu.Unlock()
 
-	// Check if we need to flush based on size
-	if len(b.logs) >= b.maxSize {
-		// Trigger immediate flush
 		select {
-		case b.flushChan <- struct{}{}:
-		default:
-			// Flush already pending
+		case <-space:
+			// Logs were taken from the buffer, try again
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
+}
+
+// flushThresholdFor returns the number of buffered logs that triggers an
+// early flush with batches of at most maxSize.
+func flushThresholdFor(maxSize int, highWaterMark float64) int {
+	if highWaterMark > 0 && highWaterMark < 1 {
+		return int(math.Ceil(highWaterMark * float64(maxSize)))
+	}
+	return maxSize
+}
+
+// SetMaxSize changes the maximum batch size of a running batcher, along with
+// the size at which a flush is triggered. Buffered logs are kept and, if they
+// already reach the new threshold, flushed. Non-positive sizes are ignored.
+func (b *Batcher) SetMaxSize(maxSize int) {
+	if maxSize <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.maxSize = maxSize
+	b.flushThreshold = flushThresholdFor(maxSize, b.highWaterMark)
+	if len(b.logs) >= b.flushThreshold {
+		b.triggerFlush()
+	}
+}
+
+// SetFlushInterval changes the flush interval of a running batcher. The
+// interval restarts from the call, so the next time-based flush happens one
+// full interval later. Non-positive intervals are ignored.
+func (b *Batcher) SetFlushInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	b.flushInterval = interval
+	b.mu.Unlock()
+
+	select {
+	case b.resetChan <- struct{}{}:
+	default:
+		// Reset already pending, it will read the new interval
+	}
+}
+
+// Pause suspends background flushes, both time- and size-based, so logs stay
+// buffered up to MaxQueueSize. Flush, FlushN, and Stop still send.
+func (b *Batcher) Pause() {
+	b.mu.Lock()
+	b.paused = true
+	b.mu.Unlock()
+}
+
+// Resume ends a Pause and triggers a flush of the logs buffered meanwhile.
+func (b *Batcher) Resume() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.paused = false
+	b.triggerFlush()
+}
+
+// flushesImmediately reports whether a log at level must be flushed right away.
+func (b *Batcher) flushesImmediately(level LogLevel) bool {
+	return b.flushLevel != "" && level.severity() >= b.flushLevel.severity()
+}
+
+// triggerFlush signals the background flusher. Callers must hold b.mu.
+func (b *Batcher) triggerFlush() {
+	select {
+	case b.flushChan <- struct{}{}:
+	default:
+		// Flush already pending
+	}
+}
 
-	return nil
+// releaseWaiters wakes up every Add blocked on a full queue. Callers must hold b.mu.
+func (b *Batcher) releaseWaiters() {
+	close(b.spaceChan)
+	b.spaceChan = make(chan struct{})
 }
 
 // Flush immediately flushes all pending logs.
 func (b *Batcher) Flush(ctx context.Context) error {
+	_, err := b.FlushN(ctx)
+	return err
+}
+
+// FlushN immediately flushes all pending logs and reports how many logs were
+// taken from the buffer and handed to the flush function. Logs are delivered
+// in batches of at most MaxSize, one after another.
+func (b *Batcher) FlushN(ctx context.Context) (int, error) {
+	batches := b.take()
+
+	var n int
+	var errs []error
+	for _, logs := range batches {
+		n += len(logs)
+		if err := b.deliver(ctx, logs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 1 {
+		return n, errs[0]
+	}
+	return n, errors.Join(errs...)
+}
+
+// take removes all buffered logs and splits them into batches of at most
+// maxSize. Each batch counts as in flight until deliver returns for it.
+func (b *Batcher) take() [][]Log {
 	b.mu.Lock()
+	defer b.mu.Unlock()
 
 	if len(b.logs) == 0 {
-		b.mu.Unlock()
 		return nil
 	}
 
-	// Take logs and reset batch
+	// Take logs, oldest first, and reset batch
 	logs := make([]Log, len(b.logs))
-	copy(logs, b.logs)
+	n := copy(logs, b.logs[b.ringStart:])
+	copy(logs[n:], b.logs[:b.ringStart])
 	b.logs = b.logs[:0] // Reset slice but keep capacity
+	b.ringStart = 0
+	b.releaseWaiters()
+
+	return b.batchesLocked(logs)
+}
+
+// takeScope removes the buffered logs of scope, keeping the others in place,
+// and splits them into batches like take.
+func (b *Batcher) takeScope(scope *flushScope) [][]Log {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var logs, kept []Log
+	for i := range b.logs {
+		log := b.logs[(b.ringStart+i)%len(b.logs)]
+		if log.scope == scope {
+			logs = append(logs, log)
+		} else {
+			kept = append(kept, log)
+		}
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+
+	b.logs = append(b.logs[:0], kept...)
+	b.ringStart = 0
+	b.releaseWaiters()
+
+	return b.batchesLocked(logs)
+}
+
+// batchesLocked orders and splits logs taken from the buffer into batches of
+// at most maxSize, counting them as in flight. Callers must hold b.mu.
+func (b *Batcher) batchesLocked(logs []Log) [][]Log {
+	if b.prioritize {
+		sort.SliceStable(logs, func(i, j int) bool {
+			return logs[i].Level.severity() > logs[j].Level.severity()
+		})
+	}
+
+	groups := [][]Log{logs}
+	if b.groupByService || b.timeWindow > 0 {
+		groups = groupLogs(logs, b.batchKey)
+	}
+
+	batches := make([][]Log, 0, len(groups)-1+(len(logs)+b.maxSize-1)/b.maxSize)
+	for _, logs := range groups {
+		if b.traceAffinity {
+			batches = append(batches, packByTrace(logs, b.maxSize)...)
+			continue
+		}
+		for len(logs) > b.maxSize {
+			batches = append(batches, logs[:b.maxSize:b.maxSize])
+			logs = logs[b.maxSize:]
+		}
+		batches = append(batches, logs)
+	}
+	b.inFlight += len(batches)
+
+	return batches
+}
+
+// batchKey identifies the logs that may share a batch.
+type batchKey struct {
+	service string
+	window  time.Time
+}
+
+// batchKey returns the key of log: its service under GroupByService and the
+// start of its time window under TimeWindow.
+func (b *Batcher) batchKey(log Log) batchKey {
+	var key batchKey
+	if b.groupByService {
+		key.service = log.Service
+	}
+	if b.timeWindow > 0 {
+		key.window = log.Time.Truncate(b.timeWindow)
+	}
+	return key
+}
+
+// groupLogs splits logs by key, keeping their order within each group and
+// ordering groups by their first log.
+func groupLogs(logs []Log, key func(Log) batchKey) [][]Log {
+	index := make(map[batchKey]int)
+	var groups [][]Log
+	for _, log := range logs {
+		k := key(log)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], log)
+	}
+	return groups
+}
+
+// packByTrace splits logs into batches of at most maxSize in which logs
+// sharing a trace ID are adjacent, ordering traces by their first log. A
+// trace that fits in a batch is never split: the batch before it is closed
+// early instead. Logs without a trace ID stay in place relative to the traces
+// around them.
+func packByTrace(logs []Log, maxSize int) [][]Log {
+	index := make(map[string]int)
+	var traces [][]Log
+	for _, log := range logs {
+		if log.TraceID == "" {
+			traces = append(traces, []Log{log})
+			continue
+		}
+		i, ok := index[log.TraceID]
+		if !ok {
+			i = len(traces)
+			index[log.TraceID] = i
+			traces = append(traces, nil)
+		}
+		traces[i] = append(traces[i], log)
+	}
+
+	var batches [][]Log
+	var batch []Log
+	for _, trace := range traces {
+		if len(batch) > 0 && len(batch)+len(trace) > maxSize && len(trace) <= maxSize {
+			batches = append(batches, batch)
+			batch = nil
+		}
+		for len(batch)+len(trace) > maxSize {
+			n := maxSize - len(batch)
+			batches = append(batches, append(batch, trace[:n]...))
+			batch, trace = nil, trace[n:]
+		}
+		batch = append(batch, trace...)
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
 
+// untilNextWindow returns the time from now to the next multiple of window.
+func untilNextWindow(now time.Time, window time.Duration) time.Duration {
+	return now.Truncate(window).Add(window).Sub(now)
+}
+
+// deliver hands a batch returned by take to the flush function and records
+// the outcome for Healthy.
+func (b *Batcher) deliver(ctx context.Context, logs []Log) error {
+	err := b.send(ctx, logs)
+	releaseScopes(logs)
+
+	b.mu.Lock()
+	if err != nil {
+		b.failures++
+	} else {
+		b.failures = 0
+		b.lastFlush = time.Now()
+	}
 	b.mu.Unlock()
 
-	// Flush logs
+	b.finishBatch()
+	return err
+}
+
+// finishBatch marks a batch returned by take as no longer in flight.
+func (b *Batcher) finishBatch() {
+	b.mu.Lock()
+	b.inFlight--
+	if b.inFlight == 0 {
+		close(b.idleChan)
+		b.idleChan = make(chan struct{})
+	}
+	b.mu.Unlock()
+}
+
+// send calls the flush function once a send slot is free, giving up with
+// ctx.Err() if ctx is done first.
+func (b *Batcher) send(ctx context.Context, logs []Log) error {
+	if b.sendSlots == nil {
+		return b.callFlush(ctx, logs)
+	}
+
+	select {
+	case b.sendSlots <- struct{}{}:
+		defer func() { <-b.sendSlots }()
+		return b.callFlush(ctx, logs)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// callFlush calls the flush function, turning a panic into an error wrapping
+// ErrFlushPanicked so that one bad batch cannot take the flusher down.
+func (b *Batcher) callFlush(ctx context.Context, logs []Log) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrFlushPanicked, r)
+		}
+	}()
 	return b.flushFunc(ctx, logs)
 }
 
-// Stop stops the batcher and flushes any remaining logs.
+// flushScoped delivers the buffered logs of scope and waits, up to the
+// context deadline, for its logs already taken by a background flush.
+func (b *Batcher) flushScoped(ctx context.Context, scope *flushScope) error {
+	var errs []error
+	for _, logs := range b.takeScope(scope) {
+		if err := b.deliver(ctx, logs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := scope.wait(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// Wait blocks until no flush is in progress or ctx is done. Logs taken from
+// the buffer before Wait is called have been handed to the flush function
+// when it returns nil.
+func (b *Batcher) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	if b.inFlight == 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	idle := b.idleChan
+	b.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// defaultStopTimeout bounds the final flush of Stop unless configured.
+const defaultStopTimeout = 10 * time.Second
+
+// Stop stops the batcher, waits for background flushes in progress, and flushes
+// any remaining logs, giving up after the stop timeout.
 func (b *Batcher) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.stopTimeout)
+	defer cancel()
+
+	return b.StopContext(ctx)
+}
+
+// StopContext stops the batcher like Stop, with background flushes in
+// progress and the final flush bounded by ctx instead. If ctx is done before
+// every remaining log is delivered, background deliveries are cancelled and
+// the returned error includes a *DeliveryError holding the undelivered logs,
+// including those of the cancelled deliveries.
+func (b *Batcher) StopContext(ctx context.Context) error {
 	b.mu.Lock()
 	if b.stopped {
 		b.mu.Unlock()
 		return nil
 	}
 	b.stopped = true
+	b.releaseWaiters()
 	b.mu.Unlock()
 
 	// Cancel background goroutine
 	b.cancel()
+	defer b.deliverCancel()
+
+	// Wait for the background goroutine and let the workers deliver the
+	// batches they were given, cutting their deliveries short once ctx is done
+	background := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		if b.work != nil {
+			close(b.work)
+			b.workers.Wait()
+		}
+		close(background)
+	}()
+	select {
+	case <-background:
+	case <-ctx.Done():
+		b.deliverCancel()
+		<-background
+	}
 
-	// Wait for background goroutine to finish
-	b.wg.Wait()
-
-	// Flush remaining logs
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Flush remaining logs, keeping the ones the deadline leaves behind
+	b.mu.Lock()
+	undelivered := b.abandoned
+	b.abandoned = nil
+	b.mu.Unlock()
+	var errs []error
+	for _, logs := range b.take() {
+		if ctx.Err() != nil {
+			releaseScopes(logs)
+			b.finishBatch()
+			undelivered = append(undelivered, logs...)
+			continue
+		}
+		if err := b.deliver(ctx, logs); err != nil {
+			if ctx.Err() != nil {
+				undelivered = append(undelivered, logs...)
+				continue
+			}
+			errs = append(errs, err)
+		}
+	}
+	if len(undelivered) > 0 {
+		errs = append(errs, &DeliveryError{Logs: undelivered, Err: ctx.Err()})
+	}
 
-	return b.Flush(ctx)
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
 }
 
 // backgroundFlusher runs in a goroutine and periodically flushes logs.
 func (b *Batcher) backgroundFlusher() {
 	defer b.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			b.mu.Lock()
+			b.flusherExited = true
+			b.mu.Unlock()
+			b.handleError(fmt.Errorf("background flusher panicked: %v", r))
+		}
+	}()
 
+	b.mu.Lock()
 	ticker := time.NewTicker(b.flushInterval)
+	b.mu.Unlock()
 	defer ticker.Stop()
 
+	// With time windows, a timer rearmed for each window edge replaces the ticker
+	tick := ticker.C
+	var edge *time.Timer
+	if b.timeWindow > 0 {
+		ticker.Stop()
+		edge = time.NewTimer(untilNextWindow(time.Now(), b.timeWindow))
+		defer edge.Stop()
+		tick = edge.C
+	}
+
 	for {
 		select {
 		case <-b.ctx.Done():
 			// Batcher stopped
 			return
 
-		case <-ticker.C:
+		case <-tick:
 			// Time-based flush
-			if err := b.Flush(b.ctx); err != nil {
-				// TODO: Consider adding error callback
-				// For now, silently continue
+			b.flushBackground()
+			if edge != nil {
+				edge.Reset(untilNextWindow(time.Now(), b.timeWindow))
 			}
 
 		case <-b.flushChan:
 			// Size-based flush
-			if err := b.Flush(b.ctx); err != nil {
-				// TODO: Consider adding error callback
-				// For now, silently continue
+			b.flushBackground()
+
+		case <-b.resetChan:
+			// Flush interval changed
+			if edge != nil {
+				continue
 			}
+			b.mu.Lock()
+			ticker.Reset(b.flushInterval)
+			b.mu.Unlock()
+		}
+	}
+}
+
+// flushBackground flushes the buffer from the background flusher, handing the
+// batches to the flush workers if there are any. It does nothing while the
+// batcher is paused.
+func (b *Batcher) flushBackground() {
+	b.mu.Lock()
+	paused := b.paused
+	b.mu.Unlock()
+	if paused {
+		return
+	}
+
+	for _, logs := range b.take() {
+		if b.work != nil {
+			// Blocks while every worker is busy, leaving new logs in the buffer
+			b.work <- logs
+			continue
 		}
+		b.deliverBackground(logs)
 	}
 }
 
+// flushWorker delivers batches from the background flusher until Stop.
+func (b *Batcher) flushWorker() {
+	defer b.workers.Done()
+
+	for logs := range b.work {
+		b.deliverBackground(logs)
+	}
+}
+
+// deliverBackground delivers a batch taken by the background flusher. It is
+// bounded by the flush timeout and deliverCtx rather than the batcher's
+// context, so Stop lets batches already taken from the buffer finish within
+// its timeout while a hung send is still abandoned eventually.
+func (b *Batcher) deliverBackground(logs []Log) {
+	ctx, cancel := b.deliverCtx, context.CancelFunc(func() {})
+	if b.flushTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, b.flushTimeout)
+	}
+	defer cancel()
+
+	if err := b.deliver(ctx, logs); err != nil {
+		if b.deliverCtx.Err() != nil {
+			// Cut short by StopContext, which reports the logs as undelivered
+			b.mu.Lock()
+			b.abandoned = append(b.abandoned, logs...)
+			b.mu.Unlock()
+			return
+		}
+		b.handleError(err)
+	}
+}
+
+// handleError reports a background flush error to the error handler, if any.
+func (b *Batcher) handleError(err error) {
+	if b.errorHandler != nil {
+		b.errorHandler(err)
+	}
+}
+
+// Healthy reports whether the batcher is running, its background flusher is
+// alive, and fewer than UnhealthyAfter deliveries have failed in a row.
+func (b *Batcher) Healthy() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stopped || b.flusherExited {
+		return false
+	}
+	return b.unhealthyAfter <= 0 || b.failures < b.unhealthyAfter
+}
+
+// LastFlush returns when a delivery last succeeded, or the zero time if none has.
+func (b *Batcher) LastFlush() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastFlush
+}
+
+// Dropped returns the number of logs evicted from the ring buffer.
+func (b *Batcher) Dropped() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
 // Size returns the current number of logs in the batch.
 func (b *Batcher) Size() int {
 	b.mu.Lock()
@@ -1,7 +1,12 @@
 package logtide
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -311,3 +316,800 @@ func TestBatcherSize(t *testing.T) {
 		t.Errorf("size after adding 5 logs = %d, want 5", batcher.Size())
 	}
 }
+
+func TestBatcherAddContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+
+	flushFunc := func(ctx context.Context, logs []Log) error {
+		<-release
+		return nil
+	}
+
+	config := &BatcherConfig{
+		MaxSize:       100,
+		FlushInterval: 1 * time.Minute,
+		FlushFunc:     flushFunc,
+		MaxQueueSize:  2,
+		Backpressure:  BackpressureBlock,
+	}
+
+	batcher := NewBatcher(config)
+	defer batcher.Stop()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+
+	// Keep adding until Add blocks on the full queue (the flusher is stuck)
+	go func() {
+		for {
+			err := batcher.AddContext(ctx, Log{
+				Time:    time.Now(),
+				Service: "test",
+				Level:   LogLevelInfo,
+				Message: "test message",
+			})
+			if err != nil {
+				errCh <- err
+				return
+			}
+		}
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if err != context.Canceled {
+			t.Errorf("AddContext() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("AddContext() did not return after context cancellation")
+	}
+}
+
+func TestBatcherQueueFullDrop(t *testing.T) {
+	flushFunc := func(ctx context.Context, logs []Log) error {
+		return nil
+	}
+
+	config := &BatcherConfig{
+		MaxSize:       100,
+		FlushInterval: 1 * time.Minute,
+		FlushFunc:     flushFunc,
+		MaxQueueSize:  2,
+	}
+
+	batcher := NewBatcher(config)
+	defer batcher.Stop()
+
+	log := Log{
+		Time:    time.Now(),
+		Service: "test",
+		Level:   LogLevelInfo,
+		Message: "test message",
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := batcher.Add(log); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	if err := batcher.Add(log); err != ErrQueueFull {
+		t.Errorf("Add() on full queue error = %v, want %v", err, ErrQueueFull)
+	}
+}
+
+func TestBatcherHighWaterMark(t *testing.T) {
+	flushed := make(chan int, 10)
+
+	flushFunc := func(ctx context.Context, logs []Log) error {
+		flushed <- len(logs)
+		return nil
+	}
+
+	config := &BatcherConfig{
+		MaxSize:       100,
+		FlushInterval: 1 * time.Minute,
+		FlushFunc:     flushFunc,
+		HighWaterMark: 0.8,
+	}
+
+	batcher := NewBatcher(config)
+	defer batcher.Stop()
+
+	for i := 0; i < 79; i++ {
+		batcher.Add(Log{
+			Time:    time.Now(),
+			Service: "test",
+			Level:   LogLevelInfo,
+			Message: "test message",
+		})
+	}
+
+	select {
+	case n := <-flushed:
+		t.Fatalf("flushed %d logs before reaching the high-water mark", n)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	batcher.Add(Log{
+		Time:    time.Now(),
+		Service: "test",
+		Level:   LogLevelInfo,
+		Message: "test message",
+	})
+
+	select {
+	case n := <-flushed:
+		if n != 80 {
+			t.Errorf("flushed %d logs, want 80", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no flush after reaching the high-water mark")
+	}
+}
+
+func TestBatcherFlushN(t *testing.T) {
+	flushFunc := func(ctx context.Context, logs []Log) error {
+		return nil
+	}
+
+	config := &BatcherConfig{
+		MaxSize:       100,
+		FlushInterval: 1 * time.Minute,
+		FlushFunc:     flushFunc,
+	}
+
+	batcher := NewBatcher(config)
+	defer batcher.Stop()
+
+	for i := 0; i < 7; i++ {
+		batcher.Add(Log{
+			Time:    time.Now(),
+			Service: "test",
+			Level:   LogLevelInfo,
+			Message: "test message",
+		})
+	}
+
+	n, err := batcher.FlushN(context.Background())
+	if err != nil {
+		t.Fatalf("FlushN() error = %v", err)
+	}
+	if n != 7 {
+		t.Errorf("FlushN() = %d, want 7", n)
+	}
+
+	// Nothing was added since the last flush
+	n, err = batcher.FlushN(context.Background())
+	if err != nil {
+		t.Fatalf("FlushN() error = %v", err)
+	}
+	if n != 0 {
+		t.Errorf("FlushN() on empty batch = %d, want 0", n)
+	}
+}
+
+func TestBatcherFlushOnLevel(t *testing.T) {
+	flushed := make(chan []Log, 10)
+
+	flushFunc := func(ctx context.Context, logs []Log) error {
+		flushed <- logs
+		return nil
+	}
+
+	config := &BatcherConfig{
+		MaxSize:       100,
+		FlushInterval: 1 * time.Minute,
+		FlushFunc:     flushFunc,
+		FlushLevel:    LogLevelError,
+	}
+
+	batcher := NewBatcher(config)
+	defer batcher.Stop()
+
+	batcher.Add(Log{Time: time.Now(), Service: "test", Level: LogLevelInfo, Message: "waits"})
+
+	select {
+	case logs := <-flushed:
+		t.Fatalf("info log flushed immediately: %v", logs)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	batcher.Add(Log{Time: time.Now(), Service: "test", Level: LogLevelError, Message: "urgent"})
+
+	select {
+	case logs := <-flushed:
+		// The buffered info log goes out with the error
+		if len(logs) != 2 || logs[1].Level != LogLevelError {
+			t.Errorf("flushed %v, want the info and error logs", logs)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("error log was not flushed immediately")
+	}
+}
+
+func TestBatcherWait(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       1,
+		FlushInterval: time.Minute,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			close(started)
+			<-release
+			return nil
+		},
+	})
+	defer batcher.Stop()
+
+	if err := batcher.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() with nothing in flight error = %v", err)
+	}
+
+	batcher.Add(Log{Time: time.Now(), Service: "test", Level: LogLevelInfo, Message: "slow"})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := batcher.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() during a flush error = %v, want context.DeadlineExceeded", err)
+	}
+
+	close(release)
+	if err := batcher.Wait(context.Background()); err != nil {
+		t.Errorf("Wait() after the flush finished error = %v", err)
+	}
+}
+
+func TestBatcherChunksToMaxSize(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       10,
+		FlushInterval: time.Minute,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			mu.Lock()
+			sizes = append(sizes, len(logs))
+			mu.Unlock()
+			return nil
+		},
+		MaxQueueSize: 100,
+	})
+	defer batcher.Stop()
+
+	// Hold the lock so the background flusher cannot take logs early
+	batcher.mu.Lock()
+	for i := 0; i < 25; i++ {
+		batcher.logs = append(batcher.logs, Log{Time: time.Now(), Service: "test", Level: LogLevelInfo, Message: "test"})
+	}
+	batcher.mu.Unlock()
+
+	n, err := batcher.FlushN(context.Background())
+	if err != nil {
+		t.Fatalf("FlushN() error = %v", err)
+	}
+	if n != 25 {
+		t.Errorf("FlushN() = %d, want 25", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []int{10, 10, 5}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("batch sizes = %v, want %v", sizes, want)
+	}
+}
+
+func TestBatcherRingBuffer(t *testing.T) {
+	var mu sync.Mutex
+	var flushed []string
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       100,
+		FlushInterval: time.Minute,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, log := range logs {
+				flushed = append(flushed, log.Message)
+			}
+			return nil
+		},
+		RingBuffer: 5,
+	})
+	defer batcher.Stop()
+
+	for i := 0; i < 12; i++ {
+		log := Log{Time: time.Now(), Service: "test", Level: LogLevelInfo, Message: fmt.Sprintf("log %d", i)}
+		if err := batcher.Add(log); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if size := batcher.Size(); size != 5 {
+		t.Errorf("Size() = %d, want 5", size)
+	}
+	if dropped := batcher.Dropped(); dropped != 7 {
+		t.Errorf("Dropped() = %d, want 7", dropped)
+	}
+
+	if err := batcher.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"log 7", "log 8", "log 9", "log 10", "log 11"}
+	if !reflect.DeepEqual(flushed, want) {
+		t.Errorf("flushed %v, want %v", flushed, want)
+	}
+}
+
+func TestBatcherPrioritize(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]Log
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       3,
+		FlushInterval: time.Minute,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			mu.Lock()
+			batches = append(batches, logs)
+			mu.Unlock()
+			return nil
+		},
+		Prioritize: true,
+	})
+	defer batcher.Stop()
+
+	// Fill the buffer directly so no background flush splits it up
+	levels := []LogLevel{LogLevelDebug, LogLevelInfo, LogLevelDebug, LogLevelError, LogLevelDebug, LogLevelCritical}
+	batcher.mu.Lock()
+	for i, level := range levels {
+		batcher.logs = append(batcher.logs, Log{Time: time.Now(), Service: "test", Level: level, Message: fmt.Sprintf("log %d", i)})
+	}
+	batcher.mu.Unlock()
+
+	if err := batcher.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var got []string
+	for _, batch := range batches {
+		for _, log := range batch {
+			got = append(got, log.Message)
+		}
+	}
+	// Most severe first, FIFO within a level
+	want := []string{"log 5", "log 3", "log 1", "log 0", "log 2", "log 4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("flush order = %v, want %v", got, want)
+	}
+	if batches[0][0].Level != LogLevelCritical {
+		t.Errorf("first batch starts with %s, want critical", batches[0][0].Level)
+	}
+}
+
+func TestBatcherSetFlushInterval(t *testing.T) {
+	flushed := make(chan int, 10)
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       100,
+		FlushInterval: 50 * time.Millisecond,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			flushed <- len(logs)
+			return nil
+		},
+	})
+	defer batcher.Stop()
+
+	// A longer interval delays the next time-based flush
+	batcher.SetFlushInterval(time.Minute)
+	batcher.Add(Log{Time: time.Now(), Service: "test", Level: LogLevelInfo, Message: "test message"})
+	select {
+	case n := <-flushed:
+		t.Fatalf("flushed %d logs within the old interval", n)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// A shorter one brings it forward without losing the buffered log
+	batcher.SetFlushInterval(20 * time.Millisecond)
+	select {
+	case n := <-flushed:
+		if n != 1 {
+			t.Errorf("flushed %d logs, want 1", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no flush after shortening the interval")
+	}
+}
+
+func TestBatcherSetMaxSize(t *testing.T) {
+	flushed := make(chan int, 10)
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       10,
+		FlushInterval: time.Minute,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			flushed <- len(logs)
+			return nil
+		},
+	})
+	defer batcher.Stop()
+
+	for i := 0; i < 3; i++ {
+		batcher.Add(Log{Time: time.Now(), Service: "test", Level: LogLevelInfo, Message: "test message"})
+	}
+	select {
+	case n := <-flushed:
+		t.Fatalf("flushed %d logs below the batch size", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Shrinking the batch size flushes a buffer already at the new size
+	batcher.SetMaxSize(3)
+	for _, want := range []int{3, 3} {
+		select {
+		case n := <-flushed:
+			if n != want {
+				t.Errorf("flushed %d logs, want %d", n, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no size-based flush at the new batch size")
+		}
+		for i := 0; i < 3; i++ {
+			batcher.Add(Log{Time: time.Now(), Service: "test", Level: LogLevelInfo, Message: "test message"})
+		}
+	}
+}
+
+func TestBatcherGroupByService(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]Log
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       2,
+		FlushInterval: time.Minute,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			mu.Lock()
+			batches = append(batches, logs)
+			mu.Unlock()
+			return nil
+		},
+		GroupByService: true,
+	})
+	defer batcher.Stop()
+
+	// Fill the buffer directly so no background flush splits it up
+	services := []string{"api", "worker", "api", "api", "worker"}
+	batcher.mu.Lock()
+	for i, service := range services {
+		batcher.logs = append(batcher.logs, Log{Time: time.Now(), Service: service, Level: LogLevelInfo, Message: fmt.Sprintf("log %d", i)})
+	}
+	batcher.mu.Unlock()
+
+	if err := batcher.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var got [][]string
+	for _, batch := range batches {
+		var messages []string
+		for _, log := range batch {
+			if log.Service != batch[0].Service {
+				t.Errorf("batch mixes services %q and %q", batch[0].Service, log.Service)
+			}
+			messages = append(messages, log.Service+":"+log.Message)
+		}
+		got = append(got, messages)
+	}
+	want := [][]string{{"api:log 0", "api:log 2"}, {"api:log 3"}, {"worker:log 1", "worker:log 4"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("batches = %v, want %v", got, want)
+	}
+}
+
+func TestBatcherStopTimeout(t *testing.T) {
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       100,
+		FlushInterval: time.Minute,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			<-ctx.Done() // a hung backend
+			return ctx.Err()
+		},
+		StopTimeout: 100 * time.Millisecond,
+	})
+	batcher.Add(Log{Time: time.Now(), Service: "test", Level: LogLevelInfo, Message: "test message"})
+
+	start := time.Now()
+	err := batcher.Stop()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Stop() took %v, want about the 100ms stop timeout", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestBatcherRecoversFromPanickingFlush(t *testing.T) {
+	var calls atomic.Int32
+	errs := make(chan error, 10)
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       1,
+		FlushInterval: time.Minute,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			if calls.Add(1) == 1 {
+				panic("bad batch")
+			}
+			return nil
+		},
+		ErrorHandler:   func(err error) { errs <- err },
+		UnhealthyAfter: 1,
+	})
+	defer batcher.Stop()
+
+	if !batcher.Healthy() {
+		t.Error("Healthy() = false before any flush, want true")
+	}
+
+	// The first background flush panics
+	batcher.Add(Log{Time: time.Now(), Service: "test", Level: LogLevelInfo, Message: "first"})
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrFlushPanicked) {
+			t.Errorf("error handler got %v, want ErrFlushPanicked", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("panicking flush was not reported to the error handler")
+	}
+	if err := batcher.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if batcher.Healthy() {
+		t.Error("Healthy() = true after a failed flush with UnhealthyAfter 1, want false")
+	}
+	if !batcher.LastFlush().IsZero() {
+		t.Errorf("LastFlush() = %v before any successful flush, want zero", batcher.LastFlush())
+	}
+
+	// The flusher survived and delivers the next batch
+	batcher.Add(Log{Time: time.Now(), Service: "test", Level: LogLevelInfo, Message: "second"})
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() < 2 || batcher.LastFlush().IsZero() {
+		if time.Now().After(deadline) {
+			t.Fatal("background flusher did not deliver after recovering from a panic")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !batcher.Healthy() {
+		t.Error("Healthy() = false after a successful flush, want true")
+	}
+
+	batcher.Stop()
+	if batcher.Healthy() {
+		t.Error("Healthy() = true after Stop, want false")
+	}
+}
+
+func TestBatcherTimeWindowFlushesAtEdges(t *testing.T) {
+	const window = 200 * time.Millisecond
+	flushed := make(chan time.Time, 10)
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       100,
+		FlushInterval: window,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			flushed <- time.Now()
+			return nil
+		},
+		TimeWindow: window,
+	})
+	defer batcher.Stop()
+
+	// Add a log a quarter into a window
+	time.Sleep(untilNextWindow(time.Now(), window) + window/4)
+	added := time.Now()
+	batcher.Add(Log{Time: added, Service: "test", Level: LogLevelInfo, Message: "test message"})
+
+	select {
+	case at := <-flushed:
+		edge := added.Truncate(window).Add(window)
+		if at.Before(edge) || at.Sub(edge) > window/4 {
+			t.Errorf("flushed %v after the log, want at the window edge %v after it", at.Sub(added), edge.Sub(added))
+		}
+	case <-time.After(2 * window):
+		t.Fatal("no flush at the window edge")
+	}
+}
+
+func TestBatcherTimeWindowSplitsBatches(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]string
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       100,
+		FlushInterval: time.Minute,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			mu.Lock()
+			defer mu.Unlock()
+			var messages []string
+			for _, log := range logs {
+				messages = append(messages, log.Message)
+			}
+			batches = append(batches, messages)
+			return nil
+		},
+		TimeWindow: time.Hour,
+	})
+	defer batcher.Stop()
+
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	for _, log := range []Log{
+		{Time: base.Add(5 * time.Minute), Message: "10:05"},
+		{Time: base.Add(70 * time.Minute), Message: "11:10"},
+		{Time: base.Add(59 * time.Minute), Message: "10:59"},
+	} {
+		log.Service, log.Level = "test", LogLevelInfo
+		batcher.Add(log)
+	}
+	if err := batcher.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := [][]string{{"10:05", "10:59"}, {"11:10"}}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("batches = %v, want one per hour window %v", batches, want)
+	}
+}
+
+// flusherGoroutines counts the running background flushers of all batchers.
+func flusherGoroutines() int {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	return bytes.Count(buf, []byte("(*Batcher).backgroundFlusher"))
+}
+
+func TestBatcherManualFlushMode(t *testing.T) {
+	var flushed atomic.Int32
+	before := flusherGoroutines()
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       3,
+		FlushInterval: 10 * time.Millisecond,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			flushed.Add(int32(len(logs)))
+			return nil
+		},
+		FlushConcurrency: 4,
+		ManualFlush:      true,
+	})
+	defer batcher.Stop()
+
+	if got := flusherGoroutines(); got > before {
+		t.Errorf("background flushers = %d after NewBatcher(), want at most %d", got, before)
+	}
+
+	log := Log{Service: "test", Level: LogLevelInfo, Message: "test message"}
+	batcher.Add(log)
+	batcher.Add(log)
+	time.Sleep(50 * time.Millisecond)
+	if got := flushed.Load(); got != 0 {
+		t.Fatalf("flushed %d logs without Flush(), want 0", got)
+	}
+
+	// The Add reaching MaxSize flushes before returning
+	batcher.Add(log)
+	if got := flushed.Load(); got != 3 {
+		t.Fatalf("flushed %d logs after a full batch, want 3", got)
+	}
+
+	batcher.Add(log)
+	if err := batcher.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if got := flushed.Load(); got != 4 {
+		t.Errorf("flushed %d logs after Flush(), want 4", got)
+	}
+
+	// Stop still flushes what is left
+	batcher.Add(log)
+	if err := batcher.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if got := flushed.Load(); got != 5 {
+		t.Errorf("flushed %d logs after Stop(), want 5", got)
+	}
+}
+
+func TestBatcherTraceAffinity(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]string
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       4,
+		FlushInterval: time.Minute,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			mu.Lock()
+			defer mu.Unlock()
+			var messages []string
+			for _, log := range logs {
+				messages = append(messages, log.Message)
+			}
+			batches = append(batches, messages)
+			return nil
+		},
+		TraceAffinity: true,
+	})
+	defer batcher.Stop()
+
+	// Three requests handled concurrently, plus a log outside any trace
+	for i := 1; i <= 3; i++ {
+		for _, trace := range []string{"a", "b", "c"} {
+			batcher.Add(Log{Service: "test", Level: LogLevelInfo, Message: fmt.Sprintf("%s%d", trace, i), TraceID: trace})
+		}
+		if i == 1 {
+			batcher.Add(Log{Service: "test", Level: LogLevelInfo, Message: "untraced"})
+		}
+	}
+	if err := batcher.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := [][]string{{"a1", "a2", "a3"}, {"b1", "b2", "b3"}, {"c1", "c2", "c3", "untraced"}}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("batches = %v, want one per trace %v", batches, want)
+	}
+}
+
+func TestPackByTrace(t *testing.T) {
+	var logs []Log
+	for _, trace := range []string{"a", "b", "a", "", "b", "a", "a", "a", "a", "c"} {
+		logs = append(logs, Log{TraceID: trace})
+	}
+
+	var got [][]string
+	for _, batch := range packByTrace(logs, 4) {
+		var traces []string
+		for _, log := range batch {
+			traces = append(traces, log.TraceID)
+		}
+		got = append(got, traces)
+	}
+
+	// A trace longer than a batch fills it and continues in the next
+	want := [][]string{{"a", "a", "a", "a"}, {"a", "a", "b", "b"}, {"", "c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("packByTrace() = %q, want %q", got, want)
+	}
+}
+
+func TestBatcherStopTimeoutBackgroundFlush(t *testing.T) {
+	started := make(chan struct{})
+	batcher := NewBatcher(&BatcherConfig{
+		MaxSize:       1,
+		FlushInterval: time.Minute,
+		FlushFunc: func(ctx context.Context, logs []Log) error {
+			close(started)
+			<-ctx.Done() // a hung backend
+			return ctx.Err()
+		},
+		FlushTimeout: time.Minute,
+		StopTimeout:  100 * time.Millisecond,
+	})
+	batcher.Add(Log{Time: time.Now(), Service: "test", Level: LogLevelInfo, Message: "in flight"})
+	<-started
+
+	start := time.Now()
+	err := batcher.Stop()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Stop() took %v, want about the 100ms stop timeout", elapsed)
+	}
+	var deliveryErr *DeliveryError
+	if !errors.As(err, &deliveryErr) {
+		t.Fatalf("Stop() error = %v, want a *DeliveryError", err)
+	}
+	if len(deliveryErr.Logs) != 1 || deliveryErr.Logs[0].Message != "in flight" {
+		t.Errorf("undelivered logs = %v, want the log of the cut-short background flush", deliveryErr.Logs)
+	}
+}
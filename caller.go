@@ -0,0 +1,75 @@
+package logtide
+
+import (
+	"runtime"
+	"strings"
+)
+
+const (
+	// callerKey is the metadata key set by WithCaller.
+	callerKey = "caller"
+
+	// sdkPackage is the import path of the SDK. Frames of functions in it or
+	// in its subpackages are skipped when looking for the caller.
+	sdkPackage = "github.com/logtide-dev/logtide-sdk-go"
+
+	// maxCallerDepth bounds the number of frames inspected for the caller.
+	maxCallerDepth = 16
+)
+
+// withCaller returns metadata with the caller of the SDK, skip frames above the
+// first frame outside it, added under callerKey. It returns metadata
+// unchanged if it already has the key or no such caller is found, and never
+// mutates its input.
+func withCaller(metadata map[string]interface{}, skip int) map[string]interface{} {
+	if _, ok := metadata[callerKey]; ok {
+		return metadata
+	}
+
+	frame, ok := callerFrame(skip)
+	if !ok {
+		return metadata
+	}
+
+	withCaller := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		withCaller[k] = v
+	}
+	withCaller[callerKey] = map[string]interface{}{
+		"function": frame.Function,
+		"file":     frame.File,
+		"line":     frame.Line,
+	}
+	return withCaller
+}
+
+// callerFrame returns the stack frame skip frames above the first one outside
+// the SDK.
+func callerFrame(skip int) (runtime.Frame, bool) {
+	var pcs [maxCallerDepth]uintptr
+	n := runtime.Callers(3, pcs[:]) // skip runtime.Callers, callerFrame, withCaller
+
+	frames := runtime.CallersFrames(pcs[:n])
+	outside := false
+	for {
+		frame, more := frames.Next()
+		if outside || !inSDK(frame.Function) {
+			if skip == 0 {
+				return frame, true
+			}
+			outside = true
+			skip--
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// inSDK reports whether function, as named in a stack frame, belongs to the
+// SDK or one of its subpackages. External test packages such as
+// "github.com/logtide-dev/logtide-sdk-go_test" do not.
+func inSDK(function string) bool {
+	rest, ok := strings.CutPrefix(function, sdkPackage)
+	return ok && (strings.HasPrefix(rest, ".") || strings.HasPrefix(rest, "/"))
+}
@@ -0,0 +1,142 @@
+package logtide_test
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	logtide "github.com/logtide-dev/logtide-sdk-go"
+)
+
+// The caller tests live outside package logtide, whose own frames the SDK
+// skips when looking for the caller.
+
+// recordingSink is a logtide.Sink recording every log it receives.
+type recordingSink struct {
+	mu   sync.Mutex
+	logs []logtide.Log
+}
+
+func (s *recordingSink) Send(ctx context.Context, logs []logtide.Log) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logs = append(s.logs, logs...)
+	return nil
+}
+
+func (s *recordingSink) Logs() []logtide.Log {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]logtide.Log(nil), s.logs...)
+}
+
+func TestClientCaller(t *testing.T) {
+	var sink recordingSink
+	client, err := logtide.New(
+		logtide.WithService("test-service"),
+		logtide.WithSink(&sink),
+		logtide.WithCaller(true),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, file, line, _ := runtime.Caller(0)
+	client.Info(ctx, "with caller", nil)
+	client.Emit(ctx, logtide.Log{Level: logtide.LogLevelInfo, Message: "caller kept", Metadata: map[string]interface{}{"caller": "custom"}})
+	client.Info(logtide.ContextWithoutCaller(ctx), "without caller", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := sink.Logs()
+	if len(logs) != 3 {
+		t.Fatalf("received %d logs, want 3", len(logs))
+	}
+	caller, _ := logs[0].Metadata["caller"].(map[string]interface{})
+	if fn, _ := caller["function"].(string); !strings.HasSuffix(fn, ".TestClientCaller") {
+		t.Errorf("caller function = %q, want TestClientCaller", fn)
+	}
+	if caller["file"] != file || caller["line"] != line+1 {
+		t.Errorf("caller = %v:%v, want %v:%v", caller["file"], caller["line"], file, line+1)
+	}
+	if logs[1].Metadata["caller"] != "custom" {
+		t.Errorf("call-site caller = %v, want %q", logs[1].Metadata["caller"], "custom")
+	}
+	if _, ok := logs[2].Metadata["caller"]; ok {
+		t.Errorf("log from ContextWithoutCaller has a caller: %v", logs[2].Metadata)
+	}
+}
+
+// logVia logs through one extra frame, like a logging library would.
+func logVia(ctx context.Context, client *logtide.Client) {
+	client.Info(logtide.ContextWithCallerSkip(ctx, 1), "via helper", nil)
+}
+
+func TestClientCallerSkip(t *testing.T) {
+	var sink recordingSink
+	client, err := logtide.New(
+		logtide.WithService("test-service"),
+		logtide.WithSink(&sink),
+		logtide.WithCaller(true),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, file, line, _ := runtime.Caller(0)
+	logVia(ctx, client)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := sink.Logs()
+	if len(logs) != 1 {
+		t.Fatalf("received %d logs, want 1", len(logs))
+	}
+	caller, _ := logs[0].Metadata["caller"].(map[string]interface{})
+	if caller["file"] != file || caller["line"] != line+1 {
+		t.Errorf("caller = %v:%v (%v), want the call of logVia at %v:%v", caller["file"], caller["line"], caller["function"], file, line+1)
+	}
+}
+
+func TestClientCallerForLevel(t *testing.T) {
+	var sink recordingSink
+	client, err := logtide.New(
+		logtide.WithService("test-service"),
+		logtide.WithSink(&sink),
+		logtide.WithCallerForLevel(logtide.LogLevelError),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "hot path", nil)
+	client.Error(ctx, "failure", nil)
+	client.Critical(ctx, "outage", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := sink.Logs()
+	if len(logs) != 3 {
+		t.Fatalf("received %d logs, want 3", len(logs))
+	}
+	if _, ok := logs[0].Metadata["caller"]; ok {
+		t.Errorf("info log has a caller: %v", logs[0].Metadata)
+	}
+	for _, log := range logs[1:] {
+		caller, _ := log.Metadata["caller"].(map[string]interface{})
+		if fn, _ := caller["function"].(string); !strings.HasSuffix(fn, ".TestClientCallerForLevel") {
+			t.Errorf("%s log caller function = %q, want TestClientCallerForLevel", log.Level, fn)
+		}
+	}
+}
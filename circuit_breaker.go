@@ -46,6 +46,9 @@ type CircuitBreaker struct {
 	failures         int       // Consecutive failure count
 	lastFailureTime  time.Time // Time of last failure
 	lastStateChange  time.Time // Time of last state change
+
+	// onStateChange, if set, is called with cb.mu held on every transition
+	onStateChange func(from, to CircuitState)
 }
 
 // CircuitBreakerConfig holds the configuration for a circuit breaker.
@@ -84,8 +87,7 @@ func (cb *CircuitBreaker) Allow() error {
 	// Check if we should transition from open to half-open
 	if cb.state == CircuitOpen {
 		if time.Since(cb.lastStateChange) >= cb.timeout {
-			cb.state = CircuitHalfOpen
-			cb.lastStateChange = time.Now()
+			cb.setState(CircuitHalfOpen)
 		} else {
 			return ErrCircuitOpen
 		}
@@ -104,8 +106,7 @@ func (cb *CircuitBreaker) RecordSuccess() {
 
 	// If we were in half-open state, transition to closed
 	if cb.state == CircuitHalfOpen {
-		cb.state = CircuitClosed
-		cb.lastStateChange = time.Now()
+		cb.setState(CircuitClosed)
 	}
 }
 
@@ -119,15 +120,23 @@ func (cb *CircuitBreaker) RecordFailure() {
 
 	// If we're in half-open state, a single failure trips the circuit
 	if cb.state == CircuitHalfOpen {
-		cb.state = CircuitOpen
-		cb.lastStateChange = time.Now()
+		cb.setState(CircuitOpen)
 		return
 	}
 
 	// Check if we've exceeded the failure threshold
 	if cb.failures >= cb.failureThreshold {
-		cb.state = CircuitOpen
-		cb.lastStateChange = time.Now()
+		cb.setState(CircuitOpen)
+	}
+}
+
+// setState transitions to state. Callers must hold cb.mu.
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	from := cb.state
+	cb.state = state
+	cb.lastStateChange = time.Now()
+	if cb.onStateChange != nil && from != state {
+		cb.onStateChange(from, state)
 	}
 }
 
@@ -150,7 +159,6 @@ func (cb *CircuitBreaker) Reset() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
-	cb.state = CircuitClosed
 	cb.failures = 0
-	cb.lastStateChange = time.Now()
+	cb.setState(CircuitClosed)
 }
@@ -2,11 +2,23 @@ package logtide
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
 	"net/http"
+	"net/http/httptrace"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"weak"
 
+	"github.com/google/uuid"
 	internalhttp "github.com/logtide-dev/logtide-sdk-go/internal/http"
 )
 
@@ -17,9 +29,53 @@ type Client struct {
 	batcher        *Batcher
 	circuitBreaker *CircuitBreaker
 	retryConfig    *RetryConfig
+	sink           Sink
+	rateLimiter    *rateLimiter
+	stats          statsRecorder
+	batchSeq       atomic.Uint64 // sequence number of the last batch sent
+	authFailed     atomic.Bool   // shipping is paused after the API key was rejected
+	paused         atomic.Bool   // shipping is paused by Pause
+	keyMu          sync.Mutex    // guards config.APIKey, replaced by SetAPIKey
+	debug          *debugLogger
+	echo           *echoWriter // prints logs to stdout; nil unless StdoutEcho
 
-	mu     sync.RWMutex
-	closed bool
+	// baseMetadata holds fields attached to every log, below call-site metadata.
+	baseMetadata map[string]interface{}
+
+	// levelAliases maps the aliases set with WithLevelAlias, in lower case, to
+	// the level they stand for.
+	levelAliases map[string]LogLevel
+
+	// errorHandler is the configured error handler, throttled if requested.
+	// It must not reference the client, since the batcher holds it.
+	errorHandler func(error)
+
+	mu       sync.RWMutex
+	closed   bool
+	draining bool
+
+	closeAsyncOnce sync.Once
+	closeAsyncDone chan error
+
+	// unclosed stops the batcher if the client is garbage-collected without
+	// being closed.
+	unclosed runtime.Cleanup
+}
+
+// orphanedBatcher is what the garbage-collection cleanup of an unclosed client
+// needs. It must not reference the client, or the client would never become
+// unreachable.
+type orphanedBatcher struct {
+	batcher *Batcher
+	debug   *debugLogger
+}
+
+// stopOrphanedBatcher stops the batcher of a client that was garbage-collected
+// without Close, so its goroutines do not leak. Buffered logs are lost, since
+// sending them needs the client.
+func stopOrphanedBatcher(o orphanedBatcher) {
+	o.debug.unclosed(o.batcher.Size())
+	o.batcher.Stop()
 }
 
 // New creates a new LogTide client with the specified options.
@@ -32,16 +88,29 @@ func New(opts ...Option) (*Client, error) {
 		opt(config)
 	}
 
+	if config.ServiceSanitizer != nil {
+		config.Service = config.ServiceSanitizer(config.Service)
+	}
+
 	// Validate config
 	if err := config.validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	if config.DeliveryMode == AtMostOnce {
+		config.RetryConfig = retryConfig(config)
+		config.RetryConfig.MaxRetries = 0
+	}
+
 	// Create HTTP client
 	httpClient := internalhttp.NewClient(&internalhttp.Config{
-		BaseURL:   config.BaseURL,
-		APIKey:    config.APIKey,
-		Timeout:   config.Timeout,
+		BaseURL:             config.BaseURL,
+		APIKey:              config.APIKey,
+		Timeout:             config.Timeout,
+		UserAgent:           userAgent(config.UserAgent),
+		SDKVersion:          Version,
+		RequestInterceptor:  requestInterceptor(config.RequestInterceptor),
+		ResponseInterceptor: config.ResponseInterceptor,
 	})
 
 	// Create circuit breaker
@@ -53,19 +122,174 @@ func New(opts ...Option) (*Client, error) {
 		httpClient:     httpClient,
 		circuitBreaker: circuitBreaker,
 		retryConfig:    config.RetryConfig,
+		baseMetadata:   baseMetadata(config),
+		levelAliases:   clientLevelAliases(config),
+		debug:          newDebugLogger(config.Debug),
+	}
+	if config.StdoutEcho {
+		client.echo = newEchoWriter(os.Stdout, config.StdoutEchoColor)
+	}
+
+	client.errorHandler = config.ErrorHandler
+	if config.ErrorHandler != nil && config.ErrorHandlerThrottle > 0 {
+		client.errorHandler = newErrorThrottle(config.ErrorHandler, config.ErrorHandlerThrottle).handle
+	}
+
+	if config.RateLimit > 0 {
+		client.rateLimiter = newRateLimiter(config.RateLimit, config.RateLimitBurst)
 	}
 
-	// Create batcher with flush function
-	batcherConfig := &BatcherConfig{
-		MaxSize:       config.BatchSize,
-		FlushInterval: config.FlushInterval,
-		FlushFunc:     client.sendBatch,
+	// Route retry and circuit breaker events to the debug writer
+	if client.debug != nil {
+		retryConfig := *config.RetryConfig
+		retryConfig.onRetry = client.debug.retryScheduled
+		client.retryConfig = &retryConfig
+		circuitBreaker.onStateChange = client.debug.circuitChanged
 	}
-	client.batcher = NewBatcher(batcherConfig)
+
+	client.sink = client.newSink()
+	client.batcher = client.newBatcher()
+	client.unclosed = runtime.AddCleanup(client, stopOrphanedBatcher, orphanedBatcher{client.batcher, client.debug})
 
 	return client, nil
 }
 
+// newSink returns the configured sink, defaulting to the LogTide ingest API.
+func (c *Client) newSink() Sink {
+	if c.config.Sink != nil {
+		return c.config.Sink
+	}
+	if c.config.Streaming {
+		return &streamSink{client: c}
+	}
+	return &httpSink{client: c}
+}
+
+// newBatcher creates a batcher flushing to c.sendBatch. The batcher only
+// holds a weak reference to c, so that its goroutines do not keep a client
+// that was never closed alive.
+func (c *Client) newBatcher() *Batcher {
+	ref := weak.Make(c)
+	var b *Batcher
+	flush := func(ctx context.Context, logs []Log) error {
+		client := ref.Value()
+		if client == nil {
+			return ErrClientClosed
+		}
+		err := client.sendBatch(ctx, logs)
+		if errors.Is(err, ErrInvalidAPIKey) {
+			client.authFailure(b, err)
+		}
+		return err
+	}
+
+	b = NewBatcher(&BatcherConfig{
+		MaxSize:          c.config.BatchSize,
+		FlushInterval:    c.config.FlushInterval,
+		FlushFunc:        flush,
+		ErrorHandler:     c.errorHandler,
+		HighWaterMark:    c.config.HighWaterMark,
+		FlushLevel:       c.config.FlushLevel,
+		MaxQueueSize:     c.config.MaxQueueSize,
+		Backpressure:     c.config.Backpressure,
+		FlushConcurrency: c.config.FlushConcurrency,
+		MaxInFlight:      c.config.MaxInFlight,
+		RingBuffer:       c.config.RingBuffer,
+		Prioritize:       c.config.PriorityFlush,
+		GroupByService:   c.config.PerServiceBatching,
+		TraceAffinity:    c.config.TraceAffinityBatching,
+		TimeWindow:       c.config.TimeWindow,
+		FlushTimeout:     c.config.FlushTimeout,
+		StopTimeout:      c.config.CloseFlushTimeout,
+		UnhealthyAfter:   c.config.UnhealthyAfter,
+		ManualFlush:      c.config.ManualFlush,
+	})
+	if c.authFailed.Load() || c.paused.Load() {
+		b.Pause()
+	}
+	return b
+}
+
+// interceptorError marks an error returned by the request interceptor, which
+// says nothing about the health of the backend.
+type interceptorError struct {
+	err error
+}
+
+func (e *interceptorError) Error() string { return e.err.Error() }
+func (e *interceptorError) Unwrap() error { return e.err }
+
+// requestInterceptor wraps fn so that its errors are not retried and can be
+// told apart from failed requests.
+func requestInterceptor(fn func(*http.Request) error) func(*http.Request) error {
+	if fn == nil {
+		return nil
+	}
+	return func(req *http.Request) error {
+		if err := fn(req); err != nil {
+			return &permanentError{err: &interceptorError{err: err}}
+		}
+		return nil
+	}
+}
+
+// FromEnv creates a new LogTide client configured from the environment (see
+// WithEnv), with the specified options overriding environment values.
+func FromEnv(opts ...Option) (*Client, error) {
+	return New(append([]Option{WithEnv()}, opts...)...)
+}
+
+// clientLevelAliases returns the configured level aliases keyed in lower
+// case, or nil if there are none.
+func clientLevelAliases(config *Config) map[string]LogLevel {
+	if len(config.LevelAliases) == 0 {
+		return nil
+	}
+	aliases := make(map[string]LogLevel, len(config.LevelAliases))
+	for alias, level := range config.LevelAliases {
+		aliases[strings.ToLower(alias)] = level
+	}
+	return aliases
+}
+
+// normalizeLevel returns the level an alias of the client or one registered
+// with RegisterLevelAlias stands for, and any other level unchanged.
+func (c *Client) normalizeLevel(level LogLevel) LogLevel {
+	if validLogLevels[level] {
+		return level
+	}
+	if canonical, ok := c.levelAliases[strings.ToLower(string(level))]; ok {
+		return canonical
+	}
+	return normalizeLevel(level)
+}
+
+// baseMetadata builds the metadata fields derived from the configuration.
+func baseMetadata(config *Config) map[string]interface{} {
+	metadata := make(map[string]interface{}, len(config.DefaultMetadata))
+	for k, v := range config.DefaultMetadata {
+		metadata[k] = v
+	}
+	if config.ServiceVersion != "" {
+		metadata["service_version"] = config.ServiceVersion
+	}
+	if config.Environment != "" {
+		metadata["environment"] = config.Environment
+	}
+	if config.HostEnrichment {
+		// A failed hostname lookup only omits the field
+		if hostname, err := os.Hostname(); err == nil && hostname != "" {
+			metadata["host"] = hostname
+		}
+		metadata["pid"] = os.Getpid()
+	}
+	return metadata
+}
+
+// The leveled logging methods enqueue the log for asynchronous delivery and
+// return ctx.Err() if the context is already cancelled or expired. A nil error
+// means the log was accepted into the batch, not that it was delivered.
+
 // Debug sends a debug-level log.
 func (c *Client) Debug(ctx context.Context, message string, metadata map[string]interface{}) error {
 	return c.log(ctx, LogLevelDebug, message, metadata)
@@ -91,58 +315,460 @@ func (c *Client) Critical(ctx context.Context, message string, metadata map[stri
 	return c.log(ctx, LogLevelCritical, message, metadata)
 }
 
+// LogContext sends a log at the given level. If the queue is full and the
+// client uses BackpressureBlock, it waits for space until ctx is done.
+func (c *Client) LogContext(ctx context.Context, level LogLevel, message string, metadata map[string]interface{}) error {
+	return c.log(ctx, level, message, metadata)
+}
+
+// Emit sends a pre-built log. Time and Service are filled in only when zero,
+// and TraceID and SpanID only when empty, so caller-set values are kept. The
+// log then goes through the same enrichment, validation, and batching as the
+// leveled methods.
+func (c *Client) Emit(ctx context.Context, log Log) error {
+	_, err := c.emit(ctx, log)
+	return err
+}
+
+// CaptureError logs err with its message as the log message and its details
+// as metadata: "error_type" with the Go type of its innermost cause and, for
+// an *HTTPError anywhere in its chain, "status_code". The level is critical if
+// an error in the chain has a Fatal() bool method returning true, and error
+// otherwise. A nil err is ignored.
+func (c *Client) CaptureError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	level := LogLevelError
+	var fatal interface{ Fatal() bool }
+	if errors.As(err, &fatal) && fatal.Fatal() {
+		level = LogLevelCritical
+	}
+	return c.log(ctx, level, err.Error(), errorMetadata(err))
+}
+
+// LogResult sends a log at the given level like LogContext, and also reports
+// what happened to it: whether it was accepted into the batch or discarded by
+// sampling, the rate limit, or the filter. A log that could not be enqueued
+// is reported as ResultDropped together with the error.
+func (c *Client) LogResult(ctx context.Context, level LogLevel, message string, metadata map[string]interface{}) (Result, error) {
+	return c.emit(ctx, Log{
+		Level:    level,
+		Message:  message,
+		Metadata: metadata,
+	})
+}
+
 // log creates and adds a log entry to the batcher.
 func (c *Client) log(ctx context.Context, level LogLevel, message string, metadata map[string]interface{}) error {
+	_, err := c.emit(ctx, Log{
+		Level:    level,
+		Message:  message,
+		Metadata: metadata,
+	})
+	return err
+}
+
+// emit fills in defaults for log and adds it to the batcher. The client lock
+// is only held while the log is prepared, not while it is enqueued or sent,
+// so a log call blocked on a full queue cannot stall Close, Resume, or
+// SetAPIKey, which would free it.
+func (c *Client) emit(ctx context.Context, log Log) (Result, error) {
+	log, batcher, result, err := c.prepareLog(ctx, log)
+	if result != ResultAccepted || err != nil {
+		return result, err
+	}
+
+	// Send right away in sync mode
+	if c.config.SyncMode {
+		if err := c.sendBatch(ctx, []Log{log}); err != nil {
+			if errors.Is(err, ErrInvalidAPIKey) {
+				c.authFailure(batcher, err)
+			}
+			return ResultDropped, err
+		}
+		return ResultAccepted, nil
+	}
+
+	// Add to batcher
+	if err := batcher.AddContext(ctx, log); err != nil {
+		return ResultDropped, err
+	}
+	return ResultAccepted, nil
+}
+
+// prepareLog fills in defaults for log, enriches, filters, and validates it
+// under the client lock. It returns the log and the batcher to add it to with
+// ResultAccepted, or the result of discarding it.
+func (c *Client) prepareLog(ctx context.Context, log Log) (Log, *Batcher, Result, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	if c.closed {
-		return ErrClientClosed
+		return log, nil, ResultDropped, ErrClientClosed
+	}
+	if c.draining {
+		return log, nil, ResultDropped, ErrDraining
+	}
+	if c.config.AuthFailurePolicy == AuthFailureDrop && c.authFailed.Load() {
+		return log, nil, ResultDropped, ErrInvalidAPIKey
 	}
 
-	// Create log entry
-	log := Log{
-		Time:     time.Now(),
-		Service:  c.config.Service,
-		Level:    level,
-		Message:  message,
-		Metadata: metadata,
+	// Apply sampling before doing any work on the log
+	log.Level = c.normalizeLevel(log.Level)
+	if !c.sampled(ctx, log.Level) {
+		return log, nil, ResultSampled, nil
+	}
+
+	// Fill in defaults the caller left unset
+	if log.Time.IsZero() {
+		log.Time = time.Now()
+	}
+	if c.config.UTCTimestamps {
+		log.Time = log.Time.UTC()
+	}
+	if log.Service == "" {
+		log.Service = serviceFromContext(ctx)
+	}
+	log.scope = flushScopeFromContext(ctx)
+	if log.Service == "" {
+		log.Service = c.config.Service
+	}
+	log.Metadata = mergeMetadata(c.defaultMetadata(ctx), log.Metadata, c.config.MetadataMerge)
+	log.Tags = mergeTags(c.config.DefaultTags, log.Tags)
+	log.Attachments = slices.Clone(log.Attachments)
+	if c.config.MetadataSchema != nil {
+		if err := c.config.MetadataSchema.check(log.Metadata); err != nil {
+			return log, nil, ResultDropped, fmt.Errorf("invalid log: %w", err)
+		}
+	}
+	if c.config.Caller && log.Level.severity() >= c.config.CallerLevel.severity() {
+		if skip := callerSkipFromContext(ctx); skip >= 0 {
+			log.Metadata = withCaller(log.Metadata, skip)
+		}
 	}
 
 	// Enrich with context (OpenTelemetry trace/span IDs)
 	enrichLogWithContext(ctx, &log)
+	if c.config.GenerateIDs {
+		generateMissingIDs(&log)
+	}
+
+	// Flatten nested metadata for backends that only index top-level fields
+	if c.config.FlattenSeparator != "" && len(log.Metadata) > 0 {
+		log.Metadata = flattenMetadata(log.Metadata, c.config.FlattenSeparator)
+	}
+
+	// Check metadata keys against reserved log fields
+	if len(log.Metadata) > 0 {
+		metadata, err := applyReservedKeyPolicy(log.Metadata, c.config.ReservedKeyPolicy)
+		if err != nil {
+			if c.config.ReservedKeyPolicy == PolicyError {
+				return log, nil, ResultDropped, fmt.Errorf("invalid log: %w", err)
+			}
+			c.handleError(err)
+		}
+		log.Metadata = metadata
+	}
+
+	// Replace values that would fail the encoding of the whole batch
+	if len(log.Metadata) > 0 {
+		log.Metadata, _ = sanitizeMetadata(log.Metadata)
+	}
+
+	// Shorten oversized metadata values instead of rejecting the log
+	if c.config.MaxFieldValueBytes > 0 && len(log.Metadata) > 0 {
+		log.Metadata = truncateMetadata(log.Metadata, c.config.MaxFieldValueBytes)
+	}
+
+	// Let the caller discard logs it does not want
+	if c.config.Filter != nil && !c.config.Filter(log) {
+		return log, nil, ResultFiltered, nil
+	}
 
 	// Validate log
 	if err := validateLog(&log); err != nil {
-		return fmt.Errorf("invalid log: %w", err)
+		return log, nil, ResultDropped, fmt.Errorf("invalid log: %w", err)
+	}
+	if c.config.MaxLogBytes > 0 {
+		if err := validateLogSize(&log, c.config.MaxLogBytes); err != nil {
+			return log, nil, ResultDropped, fmt.Errorf("invalid log: %w", err)
+		}
 	}
 
-	// Add to batcher
-	return c.batcher.Add(log)
+	// Only spend rate limit tokens on logs that would otherwise be sent
+	if c.rateLimiter != nil && !isForceKeep(ctx) && !c.rateLimiter.allow() {
+		return log, nil, ResultRateLimited, nil
+	}
+
+	if c.echo != nil {
+		c.echo.print(&log)
+	}
+	return log, c.batcher, ResultAccepted, nil
+}
+
+// defaultMetadata returns the fields placed below call-site metadata: the
+// base metadata overlaid with the output of each metadata provider in turn.
+func (c *Client) defaultMetadata(ctx context.Context) map[string]interface{} {
+	metadata := c.baseMetadata
+	for _, provider := range c.config.MetadataProviders {
+		metadata = mergeMetadata(metadata, provider(ctx), c.config.MetadataMerge)
+	}
+	return metadata
+}
+
+// sampled reports whether a log at level from ctx should be kept.
+func (c *Client) sampled(ctx context.Context, level LogLevel) bool {
+	rate := c.config.SampleRate
+	if ctxRate, ok := sampleRateFromContext(ctx); ok {
+		rate = ctxRate
+	}
+	if rate >= 1 || level.severity() >= LogLevelError.severity() || isForceKeep(ctx) {
+		return true
+	}
+	return rand.Float64() < rate
 }
 
-// sendBatch sends a batch of logs to the LogTide API.
+// handleError reports an error to the configured error handler, if any.
+func (c *Client) handleError(err error) {
+	if c.errorHandler != nil {
+		c.errorHandler(err)
+	}
+}
+
+// sendBatch validates a batch of logs and hands it to the configured sink.
 func (c *Client) sendBatch(ctx context.Context, logs []Log) error {
+	c.stats.recordQueueWait(logs, time.Now())
+
+	// Drop logs that went stale while buffered
+	if c.config.MaxLogAge > 0 {
+		var expired int
+		logs, expired = dropExpired(logs, time.Now().Add(-c.config.MaxLogAge))
+		if expired > 0 {
+			c.stats.recordExpired(expired)
+			c.debug.expired(expired, c.config.MaxLogAge)
+		}
+		if len(logs) == 0 {
+			return nil
+		}
+	}
+
+	if c.config.BatchTransform != nil {
+		transformed, err := c.config.BatchTransform(ctx, logs)
+		if err != nil {
+			err = fmt.Errorf("batch transform: %w", err)
+			c.deadLetter(logs, err)
+			return err
+		}
+		if len(transformed) == 0 {
+			return nil
+		}
+
+		// The transform may have changed logs after they were sized
+		for i := range transformed {
+			transformed[i].encoded = nil
+		}
+		logs = transformed
+	}
+
 	// Validate batch
 	if err := validateBatch(logs); err != nil {
 		return fmt.Errorf("invalid batch: %w", err)
 	}
 
+	start := time.Now()
+	err := c.sink.Send(ctx, logs)
+	elapsed := time.Since(start)
+	c.stats.recordFlush(elapsed, err)
+	c.debug.flushed(len(logs), elapsed, err)
+
+	// The ingest API path dead-letters on its own, after retries
+	if err != nil && c.config.Sink != nil {
+		c.deadLetter(logs, err)
+	}
+	return err
+}
+
+// dropExpired returns the logs with a time at or after cutoff and the number
+// of logs removed. It returns logs itself when none are removed and never
+// modifies it.
+func dropExpired(logs []Log, cutoff time.Time) ([]Log, int) {
+	for i, log := range logs {
+		if !log.Time.Before(cutoff) {
+			continue
+		}
+
+		// Copy the fresh logs so far, then filter the rest
+		fresh := append(make([]Log, 0, len(logs)-1), logs[:i]...)
+		for _, log := range logs[i+1:] {
+			if !log.Time.Before(cutoff) {
+				fresh = append(fresh, log)
+			}
+		}
+		return fresh, len(logs) - len(fresh)
+	}
+	return logs, 0
+}
+
+// sendHTTP sends a batch of logs to the LogTide API, split into several
+// requests if it exceeds the payload limit.
+func (c *Client) sendHTTP(ctx context.Context, logs []Log) error {
+	if c.config.MaxPayloadBytes <= 0 {
+		return c.post(ctx, logs)
+	}
+
+	overhead, err := c.payloadOverhead()
+	if err != nil {
+		return fmt.Errorf("failed to encode batch: %w", err)
+	}
+	chunks, oversized, err := splitPayload(logs, c.config.TimeFormat, overhead, c.config.MaxPayloadBytes)
+	if err != nil {
+		return fmt.Errorf("failed to encode batch: %w", err)
+	}
+
+	var errs []error
+	if len(oversized) > 0 {
+		err := fmt.Errorf("%w: %d logs do not fit in a %d byte request", ErrLogTooLarge, len(oversized), c.config.MaxPayloadBytes)
+		c.deadLetter(oversized, err)
+		errs = append(errs, err)
+	}
+	for _, chunk := range chunks {
+		if err := c.post(ctx, chunk); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// post sends a batch in one request, dead-lettering it if that fails.
+func (c *Client) post(ctx context.Context, logs []Log) error {
+	err := c.postBatch(ctx, logs)
+	if err != nil {
+		c.deadLetter(logs, err)
+	}
+	return err
+}
+
+// deadLetter passes logs the client gave up on to the DeadLetter callback, if any.
+func (c *Client) deadLetter(logs []Log, err error) {
+	if c.config.DeadLetter != nil {
+		c.config.DeadLetter(logs, err)
+	}
+}
+
+// payloadOverhead returns the size of an ingest request without logs, with
+// room for the largest batch sequence number if it is sent in the body.
+func (c *Client) payloadOverhead() (int, error) {
+	req := &IngestRequest{
+		Logs:          []Log{},
+		BatchMetadata: c.batchMetadata(math.MaxUint64),
+	}
+	body, err := encodeIngestRequest(req, c.config.TimeFormat)
+	return len(body), err
+}
+
+// batchMetadata returns the batch metadata of the batch numbered seq.
+func (c *Client) batchMetadata(seq uint64) map[string]interface{} {
+	if !c.config.BatchSequenceMetadata {
+		return c.config.BatchMetadata
+	}
+
+	metadata := copyMetadata(c.config.BatchMetadata)
+	if metadata == nil {
+		metadata = make(map[string]interface{}, 1)
+	}
+	metadata["batch_sequence"] = seq
+	return metadata
+}
+
+// postBatch sends a batch of logs to the LogTide API in one request.
+func (c *Client) postBatch(ctx context.Context, logs []Log) error {
+	// Number the batch once so retries keep the same sequence number
+	seq := c.batchSeq.Add(1)
+
+	// Create request
+	req := &IngestRequest{
+		Logs:          logs,
+		BatchMetadata: c.batchMetadata(seq),
+	}
+
+	// Encode once so retries resend the same body
+	path := "/api/v1/ingest"
+	var body []byte
+	var err error
+	if c.config.OTLP {
+		path = otlpLogsPath
+		body, err = encodeOTLPRequest(logs, req.BatchMetadata)
+	} else {
+		body, err = encodeIngestRequest(req, c.config.TimeFormat)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode batch: %w", err)
+	}
+	if c.config.Compression != CompressionNone {
+		if body, err = compress(c.config.Compression, body); err != nil {
+			return fmt.Errorf("failed to compress batch: %w", err)
+		}
+	}
+
 	// Check circuit breaker
 	if err := c.circuitBreaker.Allow(); err != nil {
 		return err
 	}
 
-	// Create request
-	req := &IngestRequest{
-		Logs: logs,
+	// Bound the whole retry sequence by the overall timeout
+	if c.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+		defer cancel()
 	}
 
+	// One key per batch, reused across retries, lets the server drop
+	// duplicates when a response was lost after the batch was stored
+	header := http.Header{}
+	if c.config.IdempotencyKeys {
+		header.Set("X-Idempotency-Key", uuid.NewString())
+	}
+	header.Set("X-Client-Batch-Size", strconv.Itoa(len(logs)))
+	header.Set("X-Batch-Sequence", strconv.FormatUint(seq, 10))
+	if c.config.Compression != CompressionNone {
+		header.Set("Content-Encoding", c.config.Compression.String())
+	}
+
+	// Without a key the server cannot drop a duplicate, so optionally give up
+	// on network errors once the request may have reached it
+	guardWrites := c.config.RetryOnlyWithIdempotency && !c.config.IdempotencyKeys
+
 	// Send with retry
 	resp, err := withRetry(ctx, c.retryConfig, func(ctx context.Context) (*http.Response, error) {
-		return c.httpClient.Post(ctx, "/api/v1/ingest", req)
+		var wrote atomic.Bool
+		if guardWrites {
+			ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+				WroteHeaders: func() { wrote.Store(true) },
+			})
+		}
+
+		// Logs still waiting behind this batch, for server-side capacity planning
+		header.Set("X-Client-Queue-Depth", strconv.Itoa(c.batcher.Size()))
+		resp, err := c.httpClient.Post(ctx, path, body, header)
+		if err != nil && wrote.Load() {
+			return nil, &permanentError{err: err}
+		}
+		return resp, err
 	})
 
+	// The request never left when the interceptor failed, so the backend's
+	// health is unknown and the circuit breaker is left as is
+	var interceptErr *interceptorError
+	if errors.As(err, &interceptErr) {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+
 	// Record circuit breaker result
 	if err != nil || (resp != nil && resp.StatusCode >= 500) {
 		c.circuitBreaker.RecordFailure()
@@ -164,37 +790,298 @@ func (c *Client) sendBatch(ctx context.Context, logs []Log) error {
 		}
 	}
 
+	if c.config.OTLP {
+		return c.otlpResponse(resp, len(logs))
+	}
+
 	// Decode response
 	var ingestResp IngestResponse
 	if err := internalhttp.DecodeResponse(resp, &ingestResp); err != nil {
 		return fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	if c.config.OnAck != nil {
+		c.ack(ingestResp)
+	}
+
 	return nil
 }
 
+// ack passes an ingest response to the OnAck callback. A server timestamp
+// that cannot be parsed is reported to the error handler and passed as the
+// zero time.
+func (c *Client) ack(resp IngestResponse) {
+	serverTime, err := time.Parse(time.RFC3339Nano, resp.Timestamp)
+	if err != nil {
+		c.handleError(fmt.Errorf("failed to parse server timestamp %q: %w", resp.Timestamp, err))
+		serverTime = time.Time{}
+	}
+	c.config.OnAck(resp.Received, serverTime)
+}
+
 // Flush immediately flushes all pending logs.
 func (c *Client) Flush(ctx context.Context) error {
+	_, err := c.FlushN(ctx)
+	return err
+}
+
+// FlushN immediately flushes all pending logs and reports how many were sent.
+func (c *Client) FlushN(ctx context.Context) (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return 0, ErrClientClosed
+	}
+
+	ctx, cancel := c.flushContext(ctx)
+	defer cancel()
+	return c.batcher.FlushN(ctx)
+}
+
+// flushContext bounds a manual flush by FlushTimeout unless ctx already has
+// a deadline.
+func (c *Client) flushContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.config.FlushTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.config.FlushTimeout)
+}
+
+// FlushAndWait flushes all pending logs and then waits, up to the context
+// deadline, for any concurrently running background flush to finish. On a nil
+// return every log accepted before the call has been handed to the sink.
+func (c *Client) FlushAndWait(ctx context.Context) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return ErrClientClosed
+	}
+
+	ctx, cancel := c.flushContext(ctx)
+	defer cancel()
+	if err := c.batcher.Flush(ctx); err != nil {
+		return err
+	}
+	return c.batcher.Wait(ctx)
+}
+
+// FlushScope delivers the logs added with ctx's ContextWithFlushScope scope
+// and waits until every one of them has been handed to the sink, including
+// those a background flush took first. Other buffered logs stay queued.
+// It gives up with ctx.Err() once ctx is done, so a request context bounds
+// it by the request deadline. Without a scope in ctx it behaves like
+// FlushAndWait.
+func (c *Client) FlushScope(ctx context.Context) error {
+	scope := flushScopeFromContext(ctx)
+	if scope == nil {
+		return c.FlushAndWait(ctx)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return ErrClientClosed
+	}
+
+	ctx, cancel := c.flushContext(ctx)
+	defer cancel()
+	return c.batcher.flushScoped(ctx, scope)
+}
+
+// Config returns a copy of the client's resolved configuration, after defaults
+// and options are applied, with the API key redacted to its last four
+// characters. Changing the copy does not affect the client.
+func (c *Client) Config() Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+	return c.config.snapshot()
+}
+
+// SetBatchSize changes the maximum batch size of a running client, for
+// example from an admin endpoint. Buffered logs are kept and flushed at the
+// new size. It returns a *ValidationError for sizes WithBatchSize rejects.
+func (c *Client) SetBatchSize(size int) error {
+	if size <= 0 || size > maxBatchSize {
+		return &ValidationError{Field: "batchSize", Message: fmt.Sprintf("batch size must be between 1 and %d", maxBatchSize)}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config.BatchSize = size
+	c.batcher.SetMaxSize(size)
+	return nil
+}
+
+// SetFlushInterval changes the flush interval of a running client. The next
+// time-based flush happens one full interval after the call; buffered logs
+// are kept. It returns a *ValidationError for non-positive intervals.
+func (c *Client) SetFlushInterval(interval time.Duration) error {
+	if interval <= 0 {
+		return &ValidationError{Field: "flushInterval", Message: "flush interval must be positive"}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config.FlushInterval = interval
+	c.batcher.SetFlushInterval(interval)
+	return nil
+}
+
+// Pause stops shipping logs in the background, for example during a noisy
+// maintenance window, while logs keep buffering up to MaxQueueSize. Neither
+// the flush interval nor a full batch sends them until Resume, though Flush
+// and Close still do. Logs sent in SyncMode are not affected.
+func (c *Client) Pause() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	c.paused.Store(true)
+	c.batcher.Pause()
+}
+
+// Resume ends a Pause and flushes the logs buffered meanwhile in the
+// background. Shipping stays paused if the API key was rejected, until
+// SetAPIKey provides a new one.
+func (c *Client) Resume() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.paused.CompareAndSwap(true, false) && !c.authFailed.Load() {
+		c.batcher.Resume()
+	}
+}
+
+// Stats returns a snapshot of the client's delivery statistics.
+func (c *Client) Stats() Stats {
+	c.mu.RLock()
+	batcher := c.batcher
+	c.mu.RUnlock()
+
+	stats := c.stats.snapshot()
+	stats.Dropped = batcher.Dropped()
+	stats.LastFlush = batcher.LastFlush()
+	return stats
+}
+
+// Healthy reports whether the client is delivering logs, for use in liveness
+// checks. It returns false once the client is closed, if its background
+// flusher has died, or if the last UnhealthyAfter flushes all failed. A
+// panicking sink does not kill the flusher: the panic is recovered, reported
+// to the error handler as ErrFlushPanicked, and counted as a failed flush.
+func (c *Client) Healthy() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	if c.closed {
+		return false
+	}
+	return c.batcher.Healthy()
+}
+
+// Drain stops accepting new logs, flushes everything already buffered, and
+// waits for background flushes in progress like FlushAndWait, respecting ctx.
+// On a nil return every log accepted before Drain has been handed to the sink.
+// Logging after Drain returns ErrDraining. The client stays usable for Flush
+// until Close finalizes it.
+func (c *Client) Drain(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
 		return ErrClientClosed
 	}
+	c.draining = true
+	c.mu.Unlock()
 
-	return c.batcher.Flush(ctx)
+	return c.FlushAndWait(ctx)
 }
 
-// Close stops the client and flushes all pending logs.
+// Close stops the client and flushes all pending logs, giving up after the
+// close flush timeout (10 seconds unless set with WithCloseFlushTimeout) like
+// CloseContext. A client that is garbage-collected without Close
+// has its background goroutines stopped, but its buffered logs are lost, so
+// Close should always be called.
 func (c *Client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.CloseFlushTimeout)
+	defer cancel()
+
+	return c.CloseContext(ctx)
+}
+
+// CloseContext stops the client like Close, with the final flush bounded by
+// ctx. Logs still undelivered when ctx is done are not dropped silently: they
+// are passed to the error handler in a *DeliveryError, which is also part of
+// the returned error, so errors.As can recover them for saving elsewhere.
+func (c *Client) CloseContext(ctx context.Context) error {
 	c.mu.Lock()
 	if c.closed {
 		c.mu.Unlock()
 		return nil
 	}
 	c.closed = true
+	c.unclosed.Stop()
+	batcher, sink := c.batcher, c.sink
 	c.mu.Unlock()
 
 	// Stop batcher (will flush remaining logs)
-	return c.batcher.Stop()
+	err := batcher.StopContext(ctx)
+	var deliveryErr *DeliveryError
+	if errors.As(err, &deliveryErr) {
+		c.handleError(deliveryErr)
+	}
+
+	// End the stream once everything has been written to it
+	if stream, ok := sink.(*streamSink); ok {
+		if closeErr := stream.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+// CloseAsync starts closing the client in the background and returns a channel
+// that delivers the result of Close once and is then closed. Calling it again
+// returns the same channel.
+func (c *Client) CloseAsync() <-chan error {
+	c.closeAsyncOnce.Do(func() {
+		done := make(chan error, 1)
+		c.closeAsyncDone = done
+		go func() {
+			done <- c.Close()
+			close(done)
+		}()
+	})
+	return c.closeAsyncDone
+}
+
+// Reopen makes a closed client usable again, so tests can reuse one client
+// instead of leaking a batcher goroutine per New. It starts a new batcher,
+// reconnects a streaming sink, and resets the circuit breaker; statistics
+// keep accumulating. Reopen on an open client does nothing. It must not be
+// called while Close or CloseAsync is still running.
+func (c *Client) Reopen() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.closed {
+		return nil
+	}
+
+	if _, ok := c.sink.(*streamSink); ok {
+		c.sink = c.newSink()
+	}
+	c.batcher = c.newBatcher()
+	c.unclosed = runtime.AddCleanup(c, stopOrphanedBatcher, orphanedBatcher{c.batcher, c.debug})
+	c.circuitBreaker.Reset()
+	c.closeAsyncOnce = sync.Once{}
+	c.closeAsyncDone = nil
+	c.closed = false
+	c.draining = false
+
+	return nil
 }
@@ -1,10 +1,23 @@
 package logtide
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -298,3 +311,3149 @@ func TestClientClose(t *testing.T) {
 		t.Errorf("Info() after close error = %v, want %v", err, ErrClientClosed)
 	}
 }
+
+func TestClientFlushN(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		resp := IngestResponse{
+			Received:  len(req.Logs),
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(1*time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		client.Info(ctx, "test message", nil)
+	}
+
+	n, err := client.FlushN(ctx)
+	if err != nil {
+		t.Fatalf("FlushN() error = %v", err)
+	}
+	if n != 4 {
+		t.Errorf("FlushN() = %d, want 4", n)
+	}
+}
+
+// captureServer is a mock ingest server that records every log it receives.
+type captureServer struct {
+	*httptest.Server
+
+	mu   sync.Mutex
+	logs []Log
+}
+
+func newCaptureServer(t *testing.T) *captureServer {
+	t.Helper()
+
+	s := &captureServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		s.mu.Lock()
+		s.logs = append(s.logs, req.Logs...)
+		s.mu.Unlock()
+
+		resp := IngestResponse{
+			Received:  len(req.Logs),
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Logs returns a copy of the logs received so far.
+func (s *captureServer) Logs() []Log {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Log(nil), s.logs...)
+}
+
+func TestClientServiceVersionAndEnvironment(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithServiceVersion("1.2.3"),
+		WithEnvironment("production"),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "default fields", nil)
+	client.Info(ctx, "call-site override", map[string]interface{}{"environment": "staging"})
+	client.Flush(ctx)
+
+	logs := server.Logs()
+	if len(logs) != 2 {
+		t.Fatalf("received %d logs, want 2", len(logs))
+	}
+	if got := logs[0].Metadata["service_version"]; got != "1.2.3" {
+		t.Errorf("service_version = %v, want %q", got, "1.2.3")
+	}
+	if got := logs[0].Metadata["environment"]; got != "production" {
+		t.Errorf("environment = %v, want %q", got, "production")
+	}
+	if got := logs[1].Metadata["environment"]; got != "staging" {
+		t.Errorf("environment with call-site override = %v, want %q", got, "staging")
+	}
+}
+
+func TestServiceVersionAndEnvironmentValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		opt   Option
+		field string
+	}{
+		{name: "blank service version", opt: WithServiceVersion("   "), field: "serviceVersion"},
+		{name: "blank environment", opt: WithEnvironment(" "), field: "environment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(
+				WithAPIKey("lp_test_key"),
+				WithService("test-service"),
+				tt.opt,
+			)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("New() error = %v, want ValidationError", err)
+			}
+			if validationErr.Field != tt.field {
+				t.Errorf("ValidationError.Field = %q, want %q", validationErr.Field, tt.field)
+			}
+		})
+	}
+}
+
+func TestClientHostEnrichment(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithHostEnrichment(true),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "enriched", nil)
+	client.Info(ctx, "overridden", map[string]interface{}{"host": "custom-host"})
+	client.Flush(ctx)
+
+	logs := server.Logs()
+	if len(logs) != 2 {
+		t.Fatalf("received %d logs, want 2", len(logs))
+	}
+
+	hostname, _ := os.Hostname()
+	if got := logs[0].Metadata["host"]; got != hostname {
+		t.Errorf("host = %v, want %q", got, hostname)
+	}
+	// JSON numbers decode as float64
+	if got := logs[0].Metadata["pid"]; got != float64(os.Getpid()) {
+		t.Errorf("pid = %v, want %d", got, os.Getpid())
+	}
+	if got := logs[1].Metadata["host"]; got != "custom-host" {
+		t.Errorf("host with call-site override = %v, want %q", got, "custom-host")
+	}
+}
+
+func TestClientCancelledContext(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := client.Info(ctx, "dead context", nil); err != context.Canceled {
+		t.Errorf("Info() error = %v, want %v", err, context.Canceled)
+	}
+
+	client.Flush(context.Background())
+	if n := len(server.Logs()); n != 0 {
+		t.Errorf("received %d logs, want 0", n)
+	}
+}
+
+func TestClientBatchMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		wantSent bool
+	}{
+		{
+			name:     "configured",
+			opts:     []Option{WithBatchMetadata(map[string]interface{}{"instance_id": "i-123"})},
+			wantSent: true,
+		},
+		{
+			name:     "not configured",
+			wantSent: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies := make(chan map[string]json.RawMessage, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]json.RawMessage
+				json.NewDecoder(r.Body).Decode(&body)
+				bodies <- body
+				json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+			}))
+			defer server.Close()
+
+			opts := append([]Option{
+				WithAPIKey("lp_test_key"),
+				WithService("test-service"),
+				WithBaseURL(server.URL),
+			}, tt.opts...)
+			client, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer client.Close()
+
+			ctx := context.Background()
+			client.Info(ctx, "test message", nil)
+			if err := client.Flush(ctx); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+
+			body := <-bodies
+			raw, ok := body["batch_metadata"]
+			if ok != tt.wantSent {
+				t.Fatalf("batch_metadata present = %v, want %v", ok, tt.wantSent)
+			}
+			if ok && string(raw) != `{"instance_id":"i-123"}` {
+				t.Errorf("batch_metadata = %s, want %s", raw, `{"instance_id":"i-123"}`)
+			}
+		})
+	}
+}
+
+func TestClientPerAttemptTimeout(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// Hang the first attempt until the client gives up on it
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+			return
+		}
+		json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithTimeout(5*time.Second),
+		WithPerAttemptTimeout(100*time.Millisecond),
+		WithRetry(2, 10*time.Millisecond, 50*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "test message", nil)
+
+	start := time.Now()
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Flush() took %v, want the slow attempt abandoned after ~100ms", elapsed)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestClientDrain(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(1*time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		client.Info(ctx, "buffered", nil)
+	}
+
+	if err := client.Drain(ctx); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	if n := len(server.Logs()); n != 3 {
+		t.Errorf("received %d logs after Drain, want 3", n)
+	}
+
+	if err := client.Info(ctx, "during drain", nil); err != ErrDraining {
+		t.Errorf("Info() during drain error = %v, want %v", err, ErrDraining)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if err := client.Info(ctx, "after close", nil); err != ErrClientClosed {
+		t.Errorf("Info() after close error = %v, want %v", err, ErrClientClosed)
+	}
+}
+
+func TestClientDrainWaitsForBackgroundFlush(t *testing.T) {
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var delivered atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		received <- struct{}{}
+		<-release
+		delivered.Add(int32(len(req.Logs)))
+		json.NewEncoder(w).Encode(IngestResponse{Received: len(req.Logs)})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithBatchSize(1),
+		WithFlushInterval(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+	var releaseOnce sync.Once
+	unblock := func() { releaseOnce.Do(func() { close(release) }) }
+	defer unblock()
+
+	client.Info(context.Background(), "in flight", nil)
+	<-received // the background flush is now waiting on the server
+
+	drained := make(chan error, 1)
+	go func() { drained <- client.Drain(context.Background()) }()
+
+	select {
+	case err := <-drained:
+		t.Fatalf("Drain() = %v while a background flush was still in flight", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	unblock()
+	if err := <-drained; err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	if n := delivered.Load(); n != 1 {
+		t.Errorf("server delivered %d logs when Drain returned, want 1", n)
+	}
+}
+
+func TestClientLogTooLarge(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithMaxLogBytes(512),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	err = client.Info(ctx, "oversized", map[string]interface{}{
+		"response_body": strings.Repeat("x", 1024),
+	})
+	if !errors.Is(err, ErrLogTooLarge) {
+		t.Errorf("Info() error = %v, want %v", err, ErrLogTooLarge)
+	}
+
+	if err := client.Info(ctx, "fits", nil); err != nil {
+		t.Errorf("Info() error = %v, want nil", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Run("reads configuration from the environment", func(t *testing.T) {
+		t.Setenv(EnvAPIKey, "lp_env_key")
+		t.Setenv(EnvService, "env-service")
+		t.Setenv(EnvBaseURL, "https://env.example.com")
+
+		client, err := FromEnv()
+		if err != nil {
+			t.Fatalf("FromEnv() error = %v", err)
+		}
+		defer client.Close()
+
+		if client.config.APIKey != "lp_env_key" {
+			t.Errorf("APIKey = %q, want %q", client.config.APIKey, "lp_env_key")
+		}
+		if client.config.Service != "env-service" {
+			t.Errorf("Service = %q, want %q", client.config.Service, "env-service")
+		}
+		if client.config.BaseURL != "https://env.example.com" {
+			t.Errorf("BaseURL = %q, want %q", client.config.BaseURL, "https://env.example.com")
+		}
+	})
+
+	t.Run("explicit options override the environment", func(t *testing.T) {
+		t.Setenv(EnvAPIKey, "lp_env_key")
+		t.Setenv(EnvService, "env-service")
+
+		client, err := FromEnv(WithService("explicit-service"))
+		if err != nil {
+			t.Fatalf("FromEnv() error = %v", err)
+		}
+		defer client.Close()
+
+		if client.config.Service != "explicit-service" {
+			t.Errorf("Service = %q, want %q", client.config.Service, "explicit-service")
+		}
+		if client.config.BaseURL != "https://api.logtide.dev" {
+			t.Errorf("BaseURL = %q, want default", client.config.BaseURL)
+		}
+	})
+
+	t.Run("missing API key fails validation", func(t *testing.T) {
+		t.Setenv(EnvAPIKey, "")
+		t.Setenv(EnvService, "env-service")
+
+		_, err := FromEnv()
+		if !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("FromEnv() error = %v, want %v", err, ErrInvalidAPIKey)
+		}
+	})
+}
+
+func TestClientUserAgent(t *testing.T) {
+	userAgents := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents <- r.UserAgent()
+		json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithUserAgent("billing-api/2.1"),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "test message", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	want := "billing-api/2.1 logtide-sdk-go/" + Version
+	if got := <-userAgents; got != want {
+		t.Errorf("User-Agent = %q, want %q", got, want)
+	}
+}
+
+func TestClientCloseAsync(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(1*time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		client.Info(ctx, "test message", nil)
+	}
+
+	done := client.CloseAsync()
+	if again := client.CloseAsync(); again != done {
+		t.Error("CloseAsync() returned a different channel on the second call")
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("CloseAsync() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("CloseAsync() did not complete")
+	}
+
+	if n := len(server.Logs()); n != 5 {
+		t.Errorf("received %d logs, want 5", n)
+	}
+
+	// The channel is closed after delivering the result
+	if _, ok := <-done; ok {
+		t.Error("CloseAsync() channel delivered more than one value")
+	}
+}
+
+func TestClientDefaultMetadataAppendSlices(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithDefaultMetadata(map[string]interface{}{"tags": []string{"api"}, "region": "eu"}),
+		WithMetadataMerge(MergeAppendSlices),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "tagged", map[string]interface{}{"tags": []string{"checkout"}, "region": "us"})
+	client.Flush(ctx)
+
+	logs := server.Logs()
+	if len(logs) != 1 {
+		t.Fatalf("received %d logs, want 1", len(logs))
+	}
+
+	wantTags := []interface{}{"api", "checkout"}
+	if got := logs[0].Metadata["tags"]; !reflect.DeepEqual(got, wantTags) {
+		t.Errorf("tags = %v, want %v", got, wantTags)
+	}
+	if got := logs[0].Metadata["region"]; got != "us" {
+		t.Errorf("region = %v, want %q", got, "us")
+	}
+}
+
+func TestClientReservedKeyPolicy(t *testing.T) {
+	t.Run("warn reports to the error handler", func(t *testing.T) {
+		server := newCaptureServer(t)
+
+		var warnings []error
+		client, err := New(
+			WithAPIKey("lp_test_key"),
+			WithService("test-service"),
+			WithBaseURL(server.URL),
+			WithErrorHandler(func(err error) { warnings = append(warnings, err) }),
+		)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer client.Close()
+
+		ctx := context.Background()
+		if err := client.Info(ctx, "shadowing", map[string]interface{}{"service": "other"}); err != nil {
+			t.Errorf("Info() error = %v, want nil", err)
+		}
+		if len(warnings) != 1 {
+			t.Fatalf("error handler called %d times, want 1", len(warnings))
+		}
+		var validationErr *ValidationError
+		if !errors.As(warnings[0], &validationErr) || validationErr.Field != "metadata.service" {
+			t.Errorf("warning = %v, want ValidationError on metadata.service", warnings[0])
+		}
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		server := newCaptureServer(t)
+
+		client, err := New(
+			WithAPIKey("lp_test_key"),
+			WithService("test-service"),
+			WithBaseURL(server.URL),
+			WithReservedKeyPolicy(PolicyRename),
+		)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer client.Close()
+
+		ctx := context.Background()
+		client.Info(ctx, "shadowing", map[string]interface{}{"level": "high"})
+		client.Flush(ctx)
+
+		logs := server.Logs()
+		if len(logs) != 1 {
+			t.Fatalf("received %d logs, want 1", len(logs))
+		}
+		if logs[0].Metadata["meta_level"] != "high" {
+			t.Errorf("meta_level = %v, want %q", logs[0].Metadata["meta_level"], "high")
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		client, err := New(
+			WithAPIKey("lp_test_key"),
+			WithService("test-service"),
+			WithReservedKeyPolicy(PolicyError),
+		)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer client.Close()
+
+		err = client.Info(context.Background(), "shadowing", map[string]interface{}{"level": "high"})
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Info() error = %v, want ValidationError", err)
+		}
+	})
+}
+
+func TestClientSampling(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithSampling(0),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		client.Info(ctx, "sampled out", nil)
+	}
+	client.Error(ctx, "errors are always kept", nil)
+	client.Info(ContextForceKeep(ctx), "force kept", nil)
+	client.Flush(ctx)
+
+	logs := server.Logs()
+	if len(logs) != 2 {
+		t.Fatalf("received %d logs, want 2", len(logs))
+	}
+	if logs[0].Message != "errors are always kept" {
+		t.Errorf("logs[0].Message = %q, want %q", logs[0].Message, "errors are always kept")
+	}
+	if logs[1].Message != "force kept" {
+		t.Errorf("logs[1].Message = %q, want %q", logs[1].Message, "force kept")
+	}
+
+	// Force-kept logs bypass rate limiting too, without using up the limit
+	var sink recordingSink
+	limited, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+		WithRateLimit(0.001, 1),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer limited.Close()
+
+	for i := 0; i < 5; i++ {
+		limited.Info(ContextForceKeep(ctx), "force kept", nil)
+	}
+	limited.Info(ctx, "within the burst", nil)
+	limited.Info(ctx, "rate limited", nil)
+	limited.Flush(ctx)
+
+	if got := len(sink.Logs()); got != 6 {
+		t.Errorf("rate-limited client sent %d logs, want the 5 force-kept logs and the burst", got)
+	}
+}
+
+func TestClientContextSampleRate(t *testing.T) {
+	var sink recordingSink
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+		WithSampling(0),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	kept := ContextWithSampleRate(ctx, 1)
+	dropped := ContextWithSampleRate(ctx, 0)
+	for i := 0; i < 10; i++ {
+		client.Info(ctx, "sampled out", nil)
+		client.Info(kept, "kept by context", nil)
+		client.Info(dropped, "dropped by context", nil)
+	}
+	client.Error(dropped, "errors are always kept", nil)
+	client.Flush(ctx)
+
+	counts := make(map[string]int)
+	for _, log := range sink.Logs() {
+		counts[log.Message]++
+	}
+	want := map[string]int{"kept by context": 10, "errors are always kept": 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("received %v, want %v", counts, want)
+	}
+}
+
+func TestClientMaxFieldValueBytes(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithMaxFieldValueBytes(1024),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	body := strings.Repeat("a", 1<<20)
+	if err := client.Error(ctx, "upstream failed", map[string]interface{}{"response_body": body}); err != nil {
+		t.Fatalf("Error() error = %v", err)
+	}
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := server.Logs()
+	if len(logs) != 1 {
+		t.Fatalf("received %d logs, want 1", len(logs))
+	}
+	got, _ := logs[0].Metadata["response_body"].(string)
+	if want := body[:1024] + truncationMarker; got != want {
+		t.Errorf("response_body has %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestClientEmit(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("default-service"),
+		WithBaseURL(server.URL),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	want := Log{
+		Time:     time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		Service:  "migrated-service",
+		Level:    LogLevelWarn,
+		Message:  "imported log",
+		Metadata: map[string]interface{}{"source": "legacy"},
+		TraceID:  "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:   "00f067aa0ba902b7",
+	}
+	if err := client.Emit(ctx, want); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	// Zero fields still get defaults
+	if err := client.Emit(ctx, Log{Level: LogLevelInfo, Message: "bare log"}); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := server.Logs()
+	if len(logs) != 2 {
+		t.Fatalf("received %d logs, want 2", len(logs))
+	}
+
+	got := logs[0]
+	if !got.Time.Equal(want.Time) {
+		t.Errorf("Time = %v, want %v", got.Time, want.Time)
+	}
+	got.Time = want.Time
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Emit() sent %+v, want %+v", got, want)
+	}
+
+	if logs[1].Service != "default-service" {
+		t.Errorf("Service = %q, want default-service", logs[1].Service)
+	}
+	if logs[1].Time.IsZero() {
+		t.Error("Time was not filled in")
+	}
+}
+
+func TestClientConfig(t *testing.T) {
+	client, err := New(
+		WithAPIKey("lp_secret_key_1234"),
+		WithService("test-service"),
+		WithBatchSize(50),
+		WithRetry(5, time.Second, time.Minute),
+		WithDefaultMetadata(map[string]interface{}{"region": "eu"}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	cfg := client.Config()
+	if cfg.APIKey != "**************1234" {
+		t.Errorf("APIKey = %q, want it masked to the last 4 characters", cfg.APIKey)
+	}
+	if cfg.Service != "test-service" {
+		t.Errorf("Service = %q, want test-service", cfg.Service)
+	}
+	if cfg.BatchSize != 50 {
+		t.Errorf("BatchSize = %d, want 50", cfg.BatchSize)
+	}
+	if cfg.FlushInterval != 5*time.Second {
+		t.Errorf("FlushInterval = %v, want the 5s default", cfg.FlushInterval)
+	}
+	if cfg.RetryConfig.MaxRetries != 5 {
+		t.Errorf("RetryConfig.MaxRetries = %d, want 5", cfg.RetryConfig.MaxRetries)
+	}
+
+	// Modifying the copy must not affect the client
+	cfg.RetryConfig.MaxRetries = 0
+	cfg.DefaultMetadata["region"] = "us"
+	again := client.Config()
+	if again.RetryConfig.MaxRetries != 5 {
+		t.Error("modifying the returned RetryConfig changed the client")
+	}
+	if again.DefaultMetadata["region"] != "eu" {
+		t.Error("modifying the returned DefaultMetadata changed the client")
+	}
+}
+
+func TestBatchSizeAndFlushIntervalValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		opt   Option
+		field string
+	}{
+		{name: "zero batch size", opt: WithBatchSize(0), field: "batchSize"},
+		{name: "negative batch size", opt: WithBatchSize(-1), field: "batchSize"},
+		{name: "batch size above server limit", opt: WithBatchSize(1001), field: "batchSize"},
+		{name: "zero flush interval", opt: WithFlushInterval(0), field: "flushInterval"},
+		{name: "negative flush interval", opt: WithFlushInterval(-time.Second), field: "flushInterval"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(
+				WithAPIKey("lp_test_key"),
+				WithService("test-service"),
+				tt.opt,
+			)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("New() error = %v, want ValidationError", err)
+			}
+			if validationErr.Field != tt.field {
+				t.Errorf("ValidationError.Field = %q, want %q", validationErr.Field, tt.field)
+			}
+		})
+	}
+
+	client, err := New(WithAPIKey("lp_test_key"), WithService("test-service"), WithBatchSize(1000))
+	if err != nil {
+		t.Fatalf("New() with the maximum batch size error = %v", err)
+	}
+	client.Close()
+}
+
+func TestBaseURLValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		wantErr bool
+	}{
+		{name: "missing scheme", baseURL: "api.logtide.dev", wantErr: true},
+		{name: "unsupported scheme", baseURL: "ftp://api.logtide.dev", wantErr: true},
+		{name: "missing host", baseURL: "https://", wantErr: true},
+		{name: "missing host with path", baseURL: "http:///api", wantErr: true},
+		{name: "valid https URL", baseURL: "https://api.logtide.dev", wantErr: false},
+		{name: "valid http URL with port", baseURL: "http://localhost:8080", wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(
+				WithAPIKey("lp_test_key"),
+				WithService("test-service"),
+				WithBaseURL(tt.baseURL),
+			)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("New() error = %v, want nil", err)
+				}
+				client.Close()
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("New() error = %v, want ValidationError", err)
+			}
+			if validationErr.Field != "baseURL" {
+				t.Errorf("ValidationError.Field = %q, want baseURL", validationErr.Field)
+			}
+		})
+	}
+}
+
+func TestClientUTCTimestamps(t *testing.T) {
+	zone := time.FixedZone("UTC+9", 9*60*60)
+	local := time.Date(2025, 6, 1, 21, 0, 0, 0, zone)
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantUTC bool
+	}{
+		{name: "default normalizes to UTC", wantUTC: true},
+		{name: "disabled keeps the zone", opts: []Option{WithUTCTimestamps(false)}, wantUTC: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sink recordingSink
+			client, err := New(append([]Option{
+				WithService("test-service"),
+				WithSink(&sink),
+			}, tt.opts...)...)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer client.Close()
+
+			ctx := context.Background()
+			client.Emit(ctx, Log{Time: local, Level: LogLevelInfo, Message: "zoned"})
+			client.Emit(ctx, Log{Level: LogLevelInfo, Message: "unset"})
+			if err := client.Flush(ctx); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+
+			logs := sink.Logs()
+			if len(logs) != 2 {
+				t.Fatalf("received %d logs, want 2", len(logs))
+			}
+			if !logs[0].Time.Equal(local) {
+				t.Errorf("Time = %v, want the same instant as %v", logs[0].Time, local)
+			}
+			if gotUTC := logs[0].Time.Location() == time.UTC; gotUTC != tt.wantUTC {
+				t.Errorf("caller time location = %v, want UTC %v", logs[0].Time.Location(), tt.wantUTC)
+			}
+			if tt.wantUTC && logs[1].Time.Location() != time.UTC {
+				t.Errorf("default time location = %v, want UTC", logs[1].Time.Location())
+			}
+		})
+	}
+}
+
+func TestClientFlushAndWait(t *testing.T) {
+	var mu sync.Mutex
+	var delivered []string
+	started := make(chan struct{})
+	var once sync.Once
+
+	sink := sinkFunc(func(ctx context.Context, logs []Log) error {
+		first := false
+		once.Do(func() { first = true })
+		if first {
+			// The background flush is slow
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, log := range logs {
+			delivered = append(delivered, log.Message)
+		}
+		return nil
+	})
+
+	client, err := New(
+		WithService("test-service"),
+		WithSink(sink),
+		WithBatchSize(1),
+		WithFlushInterval(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "background", nil)
+	<-started
+	client.Info(ctx, "manual", nil)
+
+	if err := client.FlushAndWait(ctx); err != nil {
+		t.Fatalf("FlushAndWait() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(delivered) != 2 {
+		t.Errorf("delivered %v on return, want both logs", delivered)
+	}
+}
+
+func TestClientFlushConcurrency(t *testing.T) {
+	const total = 200
+
+	deliver := func(t *testing.T, concurrency int) time.Duration {
+		var received int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req IngestRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			// Deliberately slow backend
+			time.Sleep(50 * time.Millisecond)
+			atomic.AddInt32(&received, int32(len(req.Logs)))
+			json.NewEncoder(w).Encode(IngestResponse{Received: len(req.Logs)})
+		}))
+		defer server.Close()
+
+		client, err := New(
+			WithAPIKey("lp_test_key"),
+			WithService("test-service"),
+			WithBaseURL(server.URL),
+			WithBatchSize(10),
+			WithFlushInterval(time.Minute),
+			WithFlushConcurrency(concurrency),
+		)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		start := time.Now()
+		ctx := context.Background()
+		for i := 0; i < total; i++ {
+			client.Info(ctx, fmt.Sprintf("message %d", i), nil)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for atomic.LoadInt32(&received) < total-10 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		elapsed := time.Since(start)
+
+		if err := client.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if got := atomic.LoadInt32(&received); got != total {
+			t.Errorf("concurrency %d delivered %d logs, want %d", concurrency, got, total)
+		}
+		return elapsed
+	}
+
+	serial := deliver(t, 1)
+	parallel := deliver(t, 4)
+	if parallel >= serial {
+		t.Errorf("4 workers took %v, want less than 1 worker's %v", parallel, serial)
+	}
+}
+
+func TestClientRingBuffer(t *testing.T) {
+	var sink recordingSink
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+		WithRingBuffer(3),
+		WithFlushInterval(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		if err := client.Info(ctx, fmt.Sprintf("log %d", i), nil); err != nil {
+			t.Fatalf("Info() error = %v", err)
+		}
+	}
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	var got []string
+	for _, log := range sink.Logs() {
+		got = append(got, log.Message)
+	}
+	if want := []string{"log 7", "log 8", "log 9"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+	if dropped := client.Stats().Dropped; dropped != 7 {
+		t.Errorf("Stats().Dropped = %d, want 7", dropped)
+	}
+}
+
+func TestClientUnserializableMetadata(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "good log", map[string]interface{}{"user_id": 42})
+	client.Info(ctx, "bad log", map[string]interface{}{
+		"callback": func() {},
+		"events":   make(chan int),
+	})
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := server.Logs()
+	if len(logs) != 2 {
+		t.Fatalf("received %d logs, want the whole batch of 2", len(logs))
+	}
+	if got := logs[1].Metadata["callback"]; got != "[unserializable: func()]" {
+		t.Errorf("callback = %v, want placeholder", got)
+	}
+	if got := logs[1].Metadata["events"]; got != "[unserializable: chan int]" {
+		t.Errorf("events = %v, want placeholder", got)
+	}
+}
+
+func TestClientRequestInterceptor(t *testing.T) {
+	sign := func(body []byte) string {
+		mac := hmac.New(sha256.New, []byte("gateway-secret"))
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	t.Run("signature header arrives", func(t *testing.T) {
+		var mu sync.Mutex
+		var signatures, expected []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			signatures = append(signatures, r.Header.Get("X-Signature"))
+			expected = append(expected, sign(body))
+			mu.Unlock()
+			json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+		}))
+		defer server.Close()
+
+		client, err := New(
+			WithAPIKey("lp_test_key"),
+			WithService("test-service"),
+			WithBaseURL(server.URL),
+			WithRequestInterceptor(func(req *http.Request) error {
+				body, err := req.GetBody()
+				if err != nil {
+					return err
+				}
+				data, err := io.ReadAll(body)
+				if err != nil {
+					return err
+				}
+				req.Header.Set("X-Signature", sign(data))
+				return nil
+			}),
+		)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer client.Close()
+
+		ctx := context.Background()
+		client.Info(ctx, "signed", nil)
+		if err := client.Flush(ctx); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(signatures) != 1 || signatures[0] == "" {
+			t.Fatalf("X-Signature headers = %v, want one signature", signatures)
+		}
+		if signatures[0] != expected[0] {
+			t.Errorf("X-Signature = %q, want HMAC of the body %q", signatures[0], expected[0])
+		}
+	})
+
+	t.Run("error aborts without retrying", func(t *testing.T) {
+		var requests, calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+		}))
+		defer server.Close()
+
+		errSigning := errors.New("signing key unavailable")
+		client, err := New(
+			WithAPIKey("lp_test_key"),
+			WithService("test-service"),
+			WithBaseURL(server.URL),
+			WithRetry(3, time.Millisecond, time.Millisecond),
+			WithRequestInterceptor(func(req *http.Request) error {
+				atomic.AddInt32(&calls, 1)
+				return errSigning
+			}),
+		)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer client.Close()
+
+		ctx := context.Background()
+		client.Info(ctx, "unsigned", nil)
+		if err := client.Flush(ctx); !errors.Is(err, errSigning) {
+			t.Errorf("Flush() error = %v, want %v", err, errSigning)
+		}
+		if got := atomic.LoadInt32(&calls); got != 1 {
+			t.Errorf("interceptor called %d times, want 1", got)
+		}
+		if got := atomic.LoadInt32(&requests); got != 0 {
+			t.Errorf("server received %d requests, want 0", got)
+		}
+	})
+
+	t.Run("error does not trip the circuit breaker", func(t *testing.T) {
+		server := newCaptureServer(t)
+
+		client, err := New(
+			WithAPIKey("lp_test_key"),
+			WithService("test-service"),
+			WithBaseURL(server.URL),
+			WithRetry(0, time.Millisecond, time.Millisecond),
+			WithCircuitBreaker(1, time.Minute),
+			WithRequestInterceptor(func(req *http.Request) error {
+				return errors.New("signing key unavailable")
+			}),
+		)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer client.Close()
+
+		ctx := context.Background()
+		for i := 0; i < 3; i++ {
+			client.Info(ctx, "unsigned", nil)
+			if err := client.Flush(ctx); err == nil || errors.Is(err, ErrCircuitOpen) {
+				t.Errorf("Flush() error = %v, want the interceptor error", err)
+			}
+		}
+		if state := client.circuitBreaker.State(); state != CircuitClosed {
+			t.Errorf("circuit breaker state = %v, want closed", state)
+		}
+	})
+}
+
+func TestClientResponseInterceptor(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-Request-Id", "req-123")
+		json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var statuses []int
+	var bodyHash string
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithRetry(1, time.Millisecond, time.Millisecond),
+		WithResponseInterceptor(func(resp *http.Response) {
+			mu.Lock()
+			defer mu.Unlock()
+			statuses = append(statuses, resp.StatusCode)
+			if resp.Header.Get("X-Request-Id") == "" {
+				return
+			}
+			// Hash the body and restore it for decoding
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(body)
+			bodyHash = hex.EncodeToString(sum[:])
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "test message", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []int{503, 200}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("intercepted statuses = %v, want %v", statuses, want)
+	}
+	if bodyHash == "" {
+		t.Error("interceptor did not see the response body")
+	}
+}
+
+func TestClientPriorityFlush(t *testing.T) {
+	var sink recordingSink
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+		WithBatchSize(10),
+		WithFlushInterval(time.Minute),
+		WithPriorityFlush(true),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	// Back up the queue so every log is flushed together
+	client.batcher.mu.Lock()
+	for i := 0; i < 25; i++ {
+		client.batcher.logs = append(client.batcher.logs, Log{Time: time.Now(), Service: "test-service", Level: LogLevelDebug, Message: "noise"})
+	}
+	client.batcher.mu.Unlock()
+
+	ctx := context.Background()
+	client.Critical(ctx, "disk full", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	batches := sink.Batches()
+	if len(batches) == 0 {
+		t.Fatal("no batches delivered")
+	}
+	if got := batches[0][0].Message; got != "disk full" {
+		t.Errorf("first log of the first batch = %q, want the critical log", got)
+	}
+}
+
+func TestClientFlushTimeout(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// Hang until the client gives up
+			<-r.Context().Done()
+			return
+		}
+		json.NewEncoder(w).Encode(IngestResponse{Received: len(req.Logs)})
+	}))
+	defer server.Close()
+
+	errs := make(chan error, 10)
+	delivered := make(chan struct{}, 10)
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithBatchSize(1),
+		WithFlushTimeout(100*time.Millisecond),
+		WithRetry(0, time.Millisecond, time.Millisecond),
+		WithErrorHandler(func(err error) { errs <- err }),
+		WithResponseInterceptor(func(resp *http.Response) { delivered <- struct{}{} }),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	start := time.Now()
+	client.Info(ctx, "stuck", nil)
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("background flush error = %v, want context.DeadlineExceeded", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("hung flush abandoned after %v, want about 100ms", elapsed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("hung background flush was never abandoned")
+	}
+
+	// The flusher recovers and delivers later logs
+	client.Info(ctx, "recovered", nil)
+	select {
+	case <-delivered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("flusher did not recover after the timeout")
+	}
+}
+
+func TestClientFlushDefaultTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done() // a hung backend
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(time.Minute),
+		WithFlushTimeout(100*time.Millisecond),
+		WithRetry(0, time.Millisecond, time.Millisecond),
+		WithErrorHandler(func(error) {}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	// Without a deadline, the flush timeout applies
+	client.Info(context.Background(), "stuck", nil)
+	start := time.Now()
+	err = client.Flush(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Flush(context.Background()) returned after %v, want about the 100ms flush timeout", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Flush() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	// A deadline set by the caller is used as is
+	client.Info(context.Background(), "stuck again", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	client.Flush(ctx)
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("Flush() with a 400ms deadline returned after %v, want the caller's deadline", elapsed)
+	}
+}
+
+func TestClientLogResult(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		forceKeep bool
+		want      []Result
+		err       error
+	}{
+		{
+			name: "accepted",
+			want: []Result{ResultAccepted, ResultAccepted},
+		},
+		{
+			name: "sampled",
+			opts: []Option{WithSampling(0)},
+			want: []Result{ResultSampled, ResultSampled},
+		},
+		{
+			name: "rate limited",
+			opts: []Option{WithRateLimit(0.001, 1)},
+			want: []Result{ResultAccepted, ResultRateLimited},
+		},
+		{
+			name:      "force kept past the rate limit",
+			opts:      []Option{WithRateLimit(0.001, 1)},
+			forceKeep: true,
+			want:      []Result{ResultAccepted, ResultAccepted, ResultAccepted},
+		},
+		{
+			name: "filtered",
+			opts: []Option{WithFilter(func(log Log) bool { return log.Metadata["noisy"] == nil })},
+			want: []Result{ResultFiltered, ResultFiltered},
+		},
+		{
+			name: "dropped",
+			opts: []Option{WithMaxQueueSize(1)},
+			want: []Result{ResultAccepted, ResultDropped},
+			err:  ErrQueueFull,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{
+				WithService("test-service"),
+				WithSink(&recordingSink{}),
+				WithFlushInterval(time.Minute),
+			}, tt.opts...)
+			client, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer client.Close()
+
+			ctx := context.Background()
+			if tt.forceKeep {
+				ctx = ContextForceKeep(ctx)
+			}
+			metadata := map[string]interface{}{"noisy": true}
+			for i, want := range tt.want {
+				got, err := client.LogResult(ctx, LogLevelInfo, "test message", metadata)
+				if got != want {
+					t.Errorf("LogResult() #%d = %v, want %v", i, got, want)
+				}
+				var wantErr error
+				if want == ResultDropped {
+					wantErr = tt.err
+				}
+				if !errors.Is(err, wantErr) {
+					t.Errorf("LogResult() #%d error = %v, want %v", i, err, wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestClientMetadataProvider(t *testing.T) {
+	type requestKey struct{}
+
+	var sink recordingSink
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+		WithDefaultMetadata(map[string]interface{}{"region": "eu", "layer": "default"}),
+		WithMetadataProvider(func(ctx context.Context) map[string]interface{} {
+			return map[string]interface{}{"layer": "first", "request": ctx.Value(requestKey{}), "caller": "provider"}
+		}),
+		WithMetadataProvider(func(ctx context.Context) map[string]interface{} {
+			return map[string]interface{}{"layer": "second"}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.WithValue(context.Background(), requestKey{}, "req-1")
+	if err := client.Info(ctx, "test message", map[string]interface{}{"caller": "site"}); err != nil {
+		t.Fatalf("Info() error = %v", err)
+	}
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := sink.Logs()
+	if len(logs) != 1 {
+		t.Fatalf("received %d logs, want 1", len(logs))
+	}
+	want := map[string]interface{}{
+		"region":  "eu",
+		"layer":   "second",
+		"request": "req-1",
+		"caller":  "site",
+	}
+	if !reflect.DeepEqual(logs[0].Metadata, want) {
+		t.Errorf("Metadata = %v, want %v", logs[0].Metadata, want)
+	}
+}
+
+func TestClientReopen(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Info(ctx, "before close", nil); err != nil {
+		t.Fatalf("Info() error = %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	for client.circuitBreaker.State() != CircuitOpen {
+		client.circuitBreaker.RecordFailure()
+	}
+	if err := client.Info(ctx, "while closed", nil); !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("Info() on closed client error = %v, want %v", err, ErrClientClosed)
+	}
+
+	if err := client.Reopen(); err != nil {
+		t.Fatalf("Reopen() error = %v", err)
+	}
+	if state := client.circuitBreaker.State(); state != CircuitClosed {
+		t.Errorf("circuit state after Reopen() = %v, want %v", state, CircuitClosed)
+	}
+	if err := client.Info(ctx, "after reopen", nil); err != nil {
+		t.Fatalf("Info() after Reopen() error = %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+
+	var got []string
+	for _, log := range server.Logs() {
+		got = append(got, log.Message)
+	}
+	if want := []string{"before close", "after reopen"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+}
+
+func TestClientStopsBatcherWhenCollectedWithoutClose(t *testing.T) {
+	var debug bytes.Buffer
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&recordingSink{}),
+		WithDebug(&debug),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	batcher := client.batcher
+	client.Info(context.Background(), "never sent", nil)
+	client = nil
+
+	stopped := func() bool {
+		batcher.mu.Lock()
+		defer batcher.mu.Unlock()
+		return batcher.stopped
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !stopped() {
+		if time.Now().After(deadline) {
+			t.Fatal("batcher still running after the client was garbage-collected")
+		}
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !strings.Contains(debug.String(), "without Close") {
+		t.Errorf("debug output = %q, want a warning that Close was not called", debug.String())
+	}
+}
+
+func TestClientGzipResponse(t *testing.T) {
+	writeGzip := func(w http.ResponseWriter, status int, v interface{}) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		zw := gzip.NewWriter(w)
+		json.NewEncoder(zw).Encode(v)
+		zw.Close()
+	}
+
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("Accept-Encoding = %q, want %q", r.Header.Get("Accept-Encoding"), "gzip")
+		}
+		if fail.Load() {
+			writeGzip(w, http.StatusBadRequest, map[string]string{"error": "bad batch"})
+			return
+		}
+		writeGzip(w, http.StatusOK, IngestResponse{Received: 1})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "test message", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() with a gzip response error = %v", err)
+	}
+
+	fail.Store(true)
+	client.Info(ctx, "test message", nil)
+	err = client.Flush(ctx)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Flush() error = %v, want an HTTPError", err)
+	}
+	if !strings.Contains(httpErr.Body, "bad batch") {
+		t.Errorf("HTTPError.Body = %q, want the decompressed body", httpErr.Body)
+	}
+}
+
+func TestClientIdempotencyKey(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		keys     []string
+		requests int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("X-Idempotency-Key"))
+		requests++
+		first := requests == 1
+		mu.Unlock()
+
+		// Lose the response to the first attempt
+		if first {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithRetry(1, time.Millisecond, time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		client.Info(ctx, "test message", nil)
+		if err := client.Flush(ctx); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(keys) != 3 {
+		t.Fatalf("server received %d requests, want 3", len(keys))
+	}
+	if keys[0] == "" {
+		t.Fatal("X-Idempotency-Key header not set")
+	}
+	if keys[1] != keys[0] {
+		t.Errorf("retry key = %q, want the original %q", keys[1], keys[0])
+	}
+	if keys[2] == keys[0] {
+		t.Errorf("second batch reused key %q", keys[2])
+	}
+}
+
+func TestClientMetricsHeaders(t *testing.T) {
+	var batchSize, queueDepth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batchSize = r.Header.Get("X-Client-Batch-Size")
+		queueDepth = r.Header.Get("X-Client-Queue-Depth")
+		json.NewEncoder(w).Encode(IngestResponse{Received: 3})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		client.Info(ctx, "still queued", nil)
+	}
+
+	batch := make([]Log, 3)
+	for i := range batch {
+		batch[i] = Log{Time: time.Now(), Service: "test-service", Level: LogLevelInfo, Message: "sent"}
+	}
+	if err := client.sendHTTP(ctx, batch); err != nil {
+		t.Fatalf("sendHTTP() error = %v", err)
+	}
+
+	if batchSize != "3" {
+		t.Errorf("X-Client-Batch-Size = %q, want %q", batchSize, "3")
+	}
+	if queueDepth != "4" {
+		t.Errorf("X-Client-Queue-Depth = %q, want %q", queueDepth, "4")
+	}
+}
+
+func TestClientMaxInFlight(t *testing.T) {
+	const total = 100
+
+	var current, peak, received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		defer atomic.AddInt32(&current, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+
+		var req IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		// Deliberately slow backend
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&received, int32(len(req.Logs)))
+		json.NewEncoder(w).Encode(IngestResponse{Received: len(req.Logs)})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithBatchSize(10),
+		WithFlushInterval(time.Minute),
+		WithFlushConcurrency(4),
+		WithMaxInFlight(2),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < total; i++ {
+		client.Info(ctx, fmt.Sprintf("message %d", i), nil)
+	}
+	// Manual flushes compete for the same slots as the workers
+	go client.Flush(ctx)
+	go client.Flush(ctx)
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&received); got != total {
+		t.Errorf("delivered %d logs, want %d", got, total)
+	}
+	if got := atomic.LoadInt32(&peak); got > 2 {
+		t.Errorf("peak concurrent requests = %d, want at most 2", got)
+	}
+}
+
+func TestClientTags(t *testing.T) {
+	var sink recordingSink
+	defaults := map[string]string{"region": "eu", "tier": "web"}
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+		WithDefaultTags(defaults),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "default tags", nil)
+	client.Emit(ctx, Log{Level: LogLevelInfo, Message: "call-site tags", Tags: map[string]string{"tier": "worker", "queue": "emails"}})
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := sink.Logs()
+	if len(logs) != 2 {
+		t.Fatalf("received %d logs, want 2", len(logs))
+	}
+	if !reflect.DeepEqual(logs[0].Tags, defaults) {
+		t.Errorf("Tags = %v, want %v", logs[0].Tags, defaults)
+	}
+	if want := map[string]string{"region": "eu", "tier": "worker", "queue": "emails"}; !reflect.DeepEqual(logs[1].Tags, want) {
+		t.Errorf("Tags = %v, want %v", logs[1].Tags, want)
+	}
+	if defaults["tier"] != "web" {
+		t.Error("default tags were modified")
+	}
+
+	data, err := json.Marshal(logs[0])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"tags":{"region":"eu","tier":"web"}`) {
+		t.Errorf("encoded log = %s, want a tags object", data)
+	}
+
+	_, err = New(WithService("test-service"), WithSink(&sink), WithDefaultTags(map[string]string{"region": strings.Repeat("x", 65)}))
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("New() with an oversized default tag error = %v, want a ValidationError", err)
+	}
+}
+
+func TestClientOnAck(t *testing.T) {
+	var timestamp atomic.Value
+	timestamp.Store("2024-03-01T12:30:45.5Z")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(IngestResponse{Received: 2, Timestamp: timestamp.Load().(string)})
+	}))
+	defer server.Close()
+
+	var (
+		mu         sync.Mutex
+		received   []int
+		serverTime []time.Time
+		errs       []error
+	)
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithOnAck(func(n int, t time.Time) {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, n)
+			serverTime = append(serverTime, t)
+		}),
+		WithErrorHandler(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "first", nil)
+	client.Info(ctx, "second", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	// A malformed timestamp is reported, not fatal
+	timestamp.Store("not a time")
+	client.Info(ctx, "third", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() with a malformed timestamp error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 || received[0] != 2 {
+		t.Fatalf("OnAck received counts %v, want two calls with 2", received)
+	}
+	if want := time.Date(2024, 3, 1, 12, 30, 45, 500000000, time.UTC); !serverTime[0].Equal(want) {
+		t.Errorf("server time = %v, want %v", serverTime[0], want)
+	}
+	if !serverTime[1].IsZero() {
+		t.Errorf("server time for a malformed timestamp = %v, want zero", serverTime[1])
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "not a time") {
+		t.Errorf("error handler got %v, want one timestamp parse error", errs)
+	}
+}
+
+func TestClientRetryOnlyWithIdempotency(t *testing.T) {
+	// The server reads each request and drops the connection without
+	// answering, as if the response had been lost
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name         string
+		baseURL      string
+		opts         []Option
+		wantAttempts int32
+	}{
+		{
+			name:         "disabled retries lost responses",
+			baseURL:      server.URL,
+			opts:         []Option{WithIdempotencyKeys(false)},
+			wantAttempts: 3,
+		},
+		{
+			name:         "enabled without keys does not retry lost responses",
+			baseURL:      server.URL,
+			opts:         []Option{WithIdempotencyKeys(false), WithRetryOnlyWithIdempotency(true)},
+			wantAttempts: 1,
+		},
+		{
+			name:         "enabled with keys retries lost responses",
+			baseURL:      server.URL,
+			opts:         []Option{WithRetryOnlyWithIdempotency(true)},
+			wantAttempts: 3,
+		},
+		{
+			name:         "enabled without keys retries refused connections",
+			baseURL:      closed.URL,
+			opts:         []Option{WithIdempotencyKeys(false), WithRetryOnlyWithIdempotency(true)},
+			wantAttempts: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			opts := append([]Option{
+				WithAPIKey("lp_test_key"),
+				WithService("test-service"),
+				WithBaseURL(tt.baseURL),
+				WithRetry(2, time.Millisecond, time.Millisecond),
+				WithRequestInterceptor(func(req *http.Request) error {
+					atomic.AddInt32(&attempts, 1)
+					return nil
+				}),
+			}, tt.opts...)
+			client, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer client.Close()
+
+			client.Info(context.Background(), "test message", nil)
+			if err := client.Flush(context.Background()); err == nil {
+				t.Error("Flush() error = nil, want a network error")
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestClientMaxLogAge(t *testing.T) {
+	var sink recordingSink
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+		WithMaxLogAge(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Emit(ctx, Log{Level: LogLevelInfo, Message: "stale", Time: time.Now().Add(-time.Hour)})
+	client.Info(ctx, "fresh", nil)
+	client.Emit(ctx, Log{Level: LogLevelInfo, Message: "also stale", Time: time.Now().Add(-2 * time.Minute)})
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := sink.Logs()
+	if len(logs) != 1 || logs[0].Message != "fresh" {
+		t.Fatalf("received %v, want only the fresh log", logs)
+	}
+	if got := client.Stats().Expired; got != 2 {
+		t.Errorf("Stats().Expired = %d, want 2", got)
+	}
+}
+
+func TestClientCompression(t *testing.T) {
+	tests := []struct {
+		format   Compression
+		encoding string
+	}{
+		{CompressionNone, ""},
+		{CompressionGzip, "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			var encoding atomic.Value
+			captured := make(chan []Log, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				encoding.Store(r.Header.Get("Content-Encoding"))
+				body := io.Reader(r.Body)
+				if r.Header.Get("Content-Encoding") == "gzip" {
+					zr, err := gzip.NewReader(r.Body)
+					if err != nil {
+						http.Error(w, err.Error(), http.StatusBadRequest)
+						return
+					}
+					defer zr.Close()
+					body = zr
+				}
+
+				var req IngestRequest
+				if err := json.NewDecoder(body).Decode(&req); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				captured <- req.Logs
+				json.NewEncoder(w).Encode(IngestResponse{Received: len(req.Logs)})
+			}))
+			defer server.Close()
+
+			client, err := New(
+				WithAPIKey("lp_test_key"),
+				WithService("test-service"),
+				WithBaseURL(server.URL),
+				WithCompression(tt.format),
+			)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer client.Close()
+
+			ctx := context.Background()
+			client.Info(ctx, "first", map[string]interface{}{"attempt": "1"})
+			client.Warn(ctx, "second", nil)
+			if err := client.Flush(ctx); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+
+			if got := encoding.Load(); got != tt.encoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+			logs := <-captured
+			if len(logs) != 2 || logs[0].Message != "first" || logs[1].Message != "second" {
+				t.Fatalf("received %+v, want the two logs", logs)
+			}
+			if logs[0].Metadata["attempt"] != "1" {
+				t.Errorf("Metadata = %v, want attempt", logs[0].Metadata)
+			}
+		})
+	}
+}
+
+func TestClientCompressionUnregistered(t *testing.T) {
+	_, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithCompression(CompressionZstd),
+	)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "compression" {
+		t.Fatalf("New() error = %v, want a compression ValidationError", err)
+	}
+}
+
+func TestClientSetBatchSizeAndFlushInterval(t *testing.T) {
+	var sink recordingSink
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+		WithFlushInterval(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	var validationErr *ValidationError
+	if err := client.SetBatchSize(0); !errors.As(err, &validationErr) {
+		t.Errorf("SetBatchSize(0) error = %v, want a ValidationError", err)
+	}
+	if err := client.SetFlushInterval(-time.Second); !errors.As(err, &validationErr) {
+		t.Errorf("SetFlushInterval(-1s) error = %v, want a ValidationError", err)
+	}
+
+	if err := client.SetBatchSize(2); err != nil {
+		t.Fatalf("SetBatchSize() error = %v", err)
+	}
+	if err := client.SetFlushInterval(30 * time.Second); err != nil {
+		t.Fatalf("SetFlushInterval() error = %v", err)
+	}
+	if cfg := client.Config(); cfg.BatchSize != 2 || cfg.FlushInterval != 30*time.Second {
+		t.Errorf("Config() BatchSize, FlushInterval = %d, %v, want 2, 30s", cfg.BatchSize, cfg.FlushInterval)
+	}
+
+	ctx := context.Background()
+	client.Info(ctx, "first", nil)
+	client.Info(ctx, "second", nil)
+	deadline := time.Now().Add(time.Second)
+	for len(sink.Logs()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := len(sink.Logs()); got != 2 {
+		t.Errorf("received %d logs, want a size-based flush of 2", got)
+	}
+}
+
+func TestClientErrorHandlerThrottle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "bad batch"})
+	}))
+	defer server.Close()
+
+	var calls atomic.Int32
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithBatchSize(1),
+		WithCircuitBreaker(1000, time.Minute),
+		WithErrorHandler(func(error) { calls.Add(1) }),
+		WithErrorHandlerThrottle(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		client.Info(ctx, "test message", nil)
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if got := client.Stats().FlushErrors; got < 5 {
+		t.Fatalf("Stats().FlushErrors = %d, want repeated failures", got)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("error handler called %d times, want 1", got)
+	}
+}
+
+func TestClientBatchSequence(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		sequences []string
+		metadata  []interface{}
+		requests  int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		sequences = append(sequences, r.Header.Get("X-Batch-Sequence"))
+		metadata = append(metadata, req.BatchMetadata["batch_sequence"])
+
+		// Fail the first attempt of the second batch
+		if requests == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(IngestResponse{Received: len(req.Logs)})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithRetry(1, time.Millisecond, time.Millisecond),
+		WithBatchSequenceMetadata(true),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		client.Info(ctx, "test message", nil)
+		if err := client.Flush(ctx); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"1", "2", "2", "3"}; !reflect.DeepEqual(sequences, want) {
+		t.Errorf("X-Batch-Sequence values = %v, want %v", sequences, want)
+	}
+	if want := []interface{}{1.0, 2.0, 2.0, 3.0}; !reflect.DeepEqual(metadata, want) {
+		t.Errorf("batch_sequence values = %v, want %v", metadata, want)
+	}
+}
+
+func TestClientCloseContextHandsOffUndeliveredLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Consume the body so the server notices the client going away
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+		json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+	}))
+	defer server.Close()
+
+	handed := make(chan *DeliveryError, 1)
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(time.Minute),
+		WithErrorHandler(func(err error) {
+			var deliveryErr *DeliveryError
+			if errors.As(err, &deliveryErr) {
+				handed <- deliveryErr
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		client.Info(context.Background(), fmt.Sprintf("log %d", i), nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = client.CloseContext(ctx)
+
+	var deliveryErr *DeliveryError
+	if !errors.As(err, &deliveryErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CloseContext() error = %v, want a DeliveryError for the deadline", err)
+	}
+	select {
+	case got := <-handed:
+		if len(got.Logs) != 3 || got.Logs[0].Message != "log 0" {
+			t.Errorf("error handler got %d logs, want the 3 undelivered ones", len(got.Logs))
+		}
+	default:
+		t.Fatal("undelivered logs did not reach the error handler")
+	}
+}
+
+func TestClientServiceSanitizer(t *testing.T) {
+	var sink recordingSink
+	client, err := New(
+		WithService("  Payments Worker/EU-West  "),
+		WithSink(&sink),
+		WithServiceSanitizer(SanitizeServiceName),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	if got := client.Config().Service; got != "payments-worker-eu-west" {
+		t.Errorf("Config().Service = %q, want %q", got, "payments-worker-eu-west")
+	}
+
+	ctx := context.Background()
+	client.Info(ctx, "test message", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if logs := sink.Logs(); len(logs) != 1 || logs[0].Service != "payments-worker-eu-west" {
+		t.Errorf("received %+v, want one log from the sanitized service", logs)
+	}
+}
+
+// shutdownError is an error reporting itself as fatal.
+type shutdownError struct{ fatal bool }
+
+func (e *shutdownError) Error() string { return "database unreachable" }
+func (e *shutdownError) Fatal() bool   { return e.fatal }
+
+func TestClientCaptureError(t *testing.T) {
+	var sink recordingSink
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	errs := []error{
+		fmt.Errorf("startup: %w", &shutdownError{fatal: true}),
+		&shutdownError{fatal: false},
+		fmt.Errorf("flush: %w", &HTTPError{StatusCode: 500, Message: "boom"}),
+		errors.New("plain failure"),
+		nil,
+	}
+	for _, err := range errs {
+		if err := client.CaptureError(ctx, err); err != nil {
+			t.Fatalf("CaptureError() error = %v", err)
+		}
+	}
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := sink.Logs()
+	if len(logs) != 4 {
+		t.Fatalf("received %d logs, want 4 with the nil error ignored", len(logs))
+	}
+	want := []struct {
+		level     LogLevel
+		message   string
+		errorType string
+	}{
+		{LogLevelCritical, "startup: database unreachable", "*logtide.shutdownError"},
+		{LogLevelError, "database unreachable", "*logtide.shutdownError"},
+		{LogLevelError, "flush: HTTP 500: boom", "*logtide.HTTPError"},
+		{LogLevelError, "plain failure", "*errors.errorString"},
+	}
+	for i, w := range want {
+		got := logs[i]
+		if got.Level != w.level || got.Message != w.message || got.Metadata["error_type"] != w.errorType {
+			t.Errorf("log %d = %s %q %v, want %s %q with error_type %s", i, got.Level, got.Message, got.Metadata, w.level, w.message, w.errorType)
+		}
+	}
+	if logs[2].Metadata["status_code"] != 500 {
+		t.Errorf("Metadata[status_code] = %v, want 500", logs[2].Metadata["status_code"])
+	}
+}
+
+func TestClientContextWithService(t *testing.T) {
+	server := newCaptureServer(t)
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("gateway"),
+		WithBaseURL(server.URL),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := ContextWithService(context.Background(), "tenant-acme")
+	client.Info(ctx, "from context", nil)
+	client.Emit(ctx, Log{Level: LogLevelInfo, Message: "explicit", Service: "billing"})
+	client.Info(context.Background(), "default", nil)
+
+	invalid := ContextWithService(context.Background(), strings.Repeat("x", 101))
+	var validationErr *ValidationError
+	if err := client.Info(invalid, "invalid service", nil); !errors.As(err, &validationErr) || validationErr.Field != "service" {
+		t.Errorf("Info() with an invalid context service error = %v, want a service ValidationError", err)
+	}
+
+	if err := client.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := server.Logs()
+	if len(logs) != 3 {
+		t.Fatalf("received %d logs, want 3", len(logs))
+	}
+	for i, want := range []string{"tenant-acme", "billing", "gateway"} {
+		if logs[i].Service != want {
+			t.Errorf("log %q Service = %q, want %q", logs[i].Message, logs[i].Service, want)
+		}
+	}
+}
+
+func TestClientSyncMode(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Logs) != 1 {
+			t.Errorf("request holds %d logs, want 1", len(req.Logs))
+		}
+		requests.Add(1)
+		json.NewEncoder(w).Encode(IngestResponse{Received: len(req.Logs)})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(time.Minute),
+		WithSyncMode(true),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for i := int32(1); i <= 3; i++ {
+		if err := client.Info(ctx, "test message", nil); err != nil {
+			t.Fatalf("Info() error = %v", err)
+		}
+		if got := requests.Load(); got != i {
+			t.Fatalf("after %d Info calls, server received %d requests", i, got)
+		}
+	}
+	if got := client.batcher.Size(); got != 0 {
+		t.Errorf("batcher holds %d logs, want 0", got)
+	}
+}
+
+func TestClientDeadLetter(t *testing.T) {
+	var status atomic.Int32
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first attempt of each batch with the current status
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(int(status.Load()))
+			return
+		}
+		json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var dead [][]Log
+	var deadErrs []error
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithRetry(2, time.Millisecond, time.Millisecond),
+		WithDeadLetter(func(logs []Log, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			dead = append(dead, logs)
+			deadErrs = append(deadErrs, err)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+
+	// A transient 500 that a retry recovers from is not dead-lettered
+	status.Store(http.StatusInternalServerError)
+	attempts.Store(0)
+	client.Info(ctx, "recovered", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() after a transient 500 error = %v", err)
+	}
+
+	// A 400 is final
+	status.Store(http.StatusBadRequest)
+	attempts.Store(0)
+	client.Info(ctx, "rejected", nil)
+	if err := client.Flush(ctx); err == nil {
+		t.Fatal("Flush() after a 400 error = nil, want an error")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(dead) != 1 || len(dead[0]) != 1 || dead[0][0].Message != "rejected" {
+		t.Fatalf("dead-lettered %v, want only the rejected batch", dead)
+	}
+	var httpErr *HTTPError
+	if !errors.As(deadErrs[0], &httpErr) || httpErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("dead letter error = %v, want an HTTP 400 error", deadErrs[0])
+	}
+}
+
+func TestClientDeadLetterSink(t *testing.T) {
+	errSink := errors.New("sink unavailable")
+	var dead []Log
+	var deadErr error
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&recordingSink{err: errSink}),
+		WithDeadLetter(func(logs []Log, err error) {
+			dead, deadErr = logs, err
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "lost", nil)
+	if err := client.Flush(ctx); !errors.Is(err, errSink) {
+		t.Errorf("Flush() error = %v, want the sink error", err)
+	}
+	if len(dead) != 1 || dead[0].Message != "lost" || !errors.Is(deadErr, errSink) {
+		t.Errorf("dead letter got %+v, %v, want the failed batch and the sink error", dead, deadErr)
+	}
+}
+
+func TestClientPerServiceBatching(t *testing.T) {
+	var mu sync.Mutex
+	var requests [][]Log
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		requests = append(requests, req.Logs)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(IngestResponse{Received: len(req.Logs)})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("gateway"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(time.Minute),
+		WithPerServiceBatching(true),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tenant := ContextWithService(context.Background(), "tenant-acme")
+	client.Info(context.Background(), "gateway log", nil)
+	client.Info(tenant, "tenant log", nil)
+	client.Info(context.Background(), "another gateway log", nil)
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 2 {
+		t.Fatalf("server received %d requests, want one per service", len(requests))
+	}
+	for _, logs := range requests {
+		for _, log := range logs {
+			if log.Service != logs[0].Service {
+				t.Errorf("request mixes services %q and %q", logs[0].Service, log.Service)
+			}
+		}
+	}
+	if len(requests[0]) != 2 || requests[0][0].Service != "gateway" || requests[1][0].Service != "tenant-acme" {
+		t.Errorf("requests = %+v, want 2 gateway logs then 1 tenant log", requests)
+	}
+}
+
+func TestClientBatchTransform(t *testing.T) {
+	server := newCaptureServer(t)
+
+	dead := make(chan []Log, 1)
+	var fail atomic.Bool
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithMaxLogBytes(1024),
+		WithBatchTransform(func(ctx context.Context, logs []Log) ([]Log, error) {
+			if fail.Load() {
+				return nil, errors.New("checksum unavailable")
+			}
+			var kept []Log
+			for _, log := range logs {
+				if log.Level == LogLevelDebug {
+					continue
+				}
+				log.Message = strings.ToUpper(log.Message)
+				kept = append(kept, log)
+			}
+			return kept, nil
+		}),
+		WithDeadLetter(func(logs []Log, err error) { dead <- logs }),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Debug(ctx, "noise", nil)
+	client.Info(ctx, "kept", nil)
+	client.Debug(ctx, "more noise", nil)
+	client.Warn(ctx, "also kept", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := server.Logs()
+	if len(logs) != 2 || logs[0].Message != "KEPT" || logs[1].Message != "ALSO KEPT" {
+		t.Fatalf("received %+v, want only the transformed non-debug logs", logs)
+	}
+
+	// A failing transform aborts the send
+	fail.Store(true)
+	client.Info(ctx, "aborted", nil)
+	if err := client.Flush(ctx); err == nil {
+		t.Error("Flush() with a failing transform error = nil, want an error")
+	}
+	if got := <-dead; len(got) != 1 || got[0].Message != "aborted" {
+		t.Errorf("dead-lettered %+v, want the aborted batch", got)
+	}
+	if got := len(server.Logs()); got != 2 {
+		t.Errorf("server received %d logs, want no more after the failed transform", got)
+	}
+}
+
+func TestClientCloseFlushTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done() // hang until the client gives up
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(time.Minute),
+		WithCloseFlushTimeout(time.Second),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	client.Info(context.Background(), "test message", nil)
+
+	start := time.Now()
+	err = client.Close()
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Error("Close() error = nil, want an error from the hung flush")
+	}
+	if elapsed < 900*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Close() took %v, want about the 1s close flush timeout", elapsed)
+	}
+}
+
+func TestClientDeliveryMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     DeliveryMode
+		attempts int32
+	}{
+		{name: "at most once", mode: AtMostOnce, attempts: 1},
+		{name: "at least once", mode: AtLeastOnce, attempts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer server.Close()
+
+			client, err := New(
+				WithAPIKey("lp_test_key"),
+				WithService("test-service"),
+				WithBaseURL(server.URL),
+				WithRetry(2, time.Millisecond, time.Millisecond),
+				WithDeliveryMode(tt.mode),
+			)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer client.Close()
+
+			ctx := context.Background()
+			client.Info(ctx, "test message", nil)
+			if err := client.Flush(ctx); err == nil {
+				t.Error("Flush() error = nil, want the 500")
+			}
+			if got := attempts.Load(); got != tt.attempts {
+				t.Errorf("server received %d attempts, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestClientHealthy(t *testing.T) {
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&recordingSink{err: errors.New("backend unavailable")}),
+		WithUnhealthyAfter(2),
+		WithErrorHandler(func(error) {}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if !client.Healthy() {
+			t.Fatalf("Healthy() = false after %d failed flushes, want true", i)
+		}
+		client.Info(ctx, "test message", nil)
+		client.Flush(ctx)
+	}
+	if client.Healthy() {
+		t.Error("Healthy() = true after 2 failed flushes with UnhealthyAfter 2, want false")
+	}
+
+	client.Close()
+	if client.Healthy() {
+		t.Error("Healthy() = true after Close, want false")
+	}
+}
+
+func TestClientFlushScope(t *testing.T) {
+	var sink recordingSink
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+		WithFlushInterval(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	reqCtx := ContextWithFlushScope(ctx)
+	client.Info(ctx, "unrelated", nil)
+	client.Info(reqCtx, "payment captured", nil)
+	client.Info(ctx, "also unrelated", nil)
+	client.Info(reqCtx, "receipt sent", nil)
+
+	if err := client.FlushScope(reqCtx); err != nil {
+		t.Fatalf("FlushScope() error = %v", err)
+	}
+
+	logs := sink.Logs()
+	if len(logs) != 2 || logs[0].Message != "payment captured" || logs[1].Message != "receipt sent" {
+		t.Fatalf("FlushScope() sent %+v, want only the request's two logs", logs)
+	}
+	if n := client.batcher.Size(); n != 2 {
+		t.Errorf("buffered logs after FlushScope() = %d, want the 2 unrelated logs", n)
+	}
+
+	// A scope with nothing buffered returns at once
+	if err := client.FlushScope(ContextWithFlushScope(ctx)); err != nil {
+		t.Errorf("FlushScope() of an empty scope error = %v", err)
+	}
+}
+
+func TestClientFlushScopeWaitsForBackgroundFlush(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	var delivered atomic.Bool
+	sink := sinkFunc(func(ctx context.Context, logs []Log) error {
+		started <- struct{}{}
+		<-release
+		delivered.Store(true)
+		return nil
+	})
+	client, err := New(
+		WithService("test-service"),
+		WithSink(sink),
+		WithFlushInterval(time.Minute),
+		WithFlushOnLevel(LogLevelError),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	// The error level triggers a background flush that takes the log first
+	reqCtx := ContextWithFlushScope(context.Background())
+	client.Error(reqCtx, "payment failed", nil)
+	<-started
+
+	// The request deadline bounds the wait
+	timeoutCtx, cancel := context.WithTimeout(reqCtx, 50*time.Millisecond)
+	defer cancel()
+	if err := client.FlushScope(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("FlushScope() with the delivery stuck error = %v, want context.DeadlineExceeded", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+	if err := client.FlushScope(reqCtx); err != nil {
+		t.Fatalf("FlushScope() error = %v", err)
+	}
+	if !delivered.Load() {
+		t.Error("FlushScope() returned before the background flush delivered the request's log")
+	}
+}
+
+func TestClientRetryableStatusCodes(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(520)
+			return
+		}
+		json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithRetry(2, time.Millisecond, time.Millisecond),
+		WithRetryableStatusCodes(520),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "behind a gateway", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Errorf("Flush() error = %v, want the 520 retried", err)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("server saw %d attempts, want 2", got)
+	}
+}
+
+func TestClientManualFlushMode(t *testing.T) {
+	server := newCaptureServer(t)
+
+	before := flusherGoroutines()
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(10*time.Millisecond),
+		WithManualFlush(true),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := flusherGoroutines(); got > before {
+		t.Errorf("background flushers = %d after New(), want at most %d", got, before)
+	}
+
+	ctx := context.Background()
+	client.Info(ctx, "first", nil)
+	time.Sleep(50 * time.Millisecond)
+	if got := len(server.Logs()); got != 0 {
+		t.Fatalf("server received %d logs before Flush(), want 0", got)
+	}
+
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if got := len(server.Logs()); got != 1 {
+		t.Fatalf("server received %d logs after Flush(), want 1", got)
+	}
+
+	client.Info(ctx, "second", nil)
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := len(server.Logs()); got != 2 {
+		t.Errorf("server received %d logs after Close(), want 2", got)
+	}
+}
+
+func TestClientPauseResume(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithBatchSize(2),
+		WithFlushInterval(10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Pause()
+	for i := 0; i < 5; i++ {
+		if err := client.Info(ctx, "maintenance", nil); err != nil {
+			t.Fatalf("Info() while paused error = %v", err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// Neither the interval nor full batches sent anything
+	if got := len(server.Logs()); got != 0 {
+		t.Fatalf("server received %d logs while paused, want 0", got)
+	}
+	if got := client.batcher.Size(); got != 5 {
+		t.Errorf("buffered logs while paused = %d, want 5", got)
+	}
+
+	client.Resume()
+	deadline := time.Now().Add(time.Second)
+	for len(server.Logs()) < 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("server received %d logs after Resume(), want 5", len(server.Logs()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClientResumeWithBlockedLogCall(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(time.Minute),
+		WithMaxQueueSize(2),
+		WithBackpressure(BackpressureBlock),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Pause()
+	client.Info(ctx, "first", nil)
+	client.Info(ctx, "second", nil)
+
+	// The queue is full and paused, so this call blocks
+	blocked := make(chan error, 1)
+	go func() { blocked <- client.Info(ctx, "third", nil) }()
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case err := <-blocked:
+		t.Fatalf("Info() on a paused full queue returned %v, want it to block", err)
+	default:
+	}
+
+	resumed := make(chan struct{})
+	go func() {
+		client.Resume()
+		close(resumed)
+	}()
+	select {
+	case <-resumed:
+	case <-time.After(time.Second):
+		t.Fatal("Resume() did not return while a log call was blocked")
+	}
+
+	select {
+	case err := <-blocked:
+		if err != nil {
+			t.Errorf("blocked Info() error = %v after Resume()", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked Info() did not return after Resume()")
+	}
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if got := len(server.Logs()); got != 3 {
+		t.Errorf("server received %d logs, want 3", got)
+	}
+}
+
+func TestClientCloseFlushTimeoutBackgroundFlush(t *testing.T) {
+	received := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		received <- struct{}{}
+		<-r.Context().Done() // hang until the client gives up
+	}))
+	defer server.Close()
+
+	var handled atomic.Int32
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithBatchSize(1),
+		WithFlushInterval(time.Minute),
+		WithFlushTimeout(5*time.Second),
+		WithRetry(0, time.Millisecond, time.Millisecond),
+		WithCloseFlushTimeout(500*time.Millisecond),
+		WithErrorHandler(func(err error) {
+			var deliveryErr *DeliveryError
+			if errors.As(err, &deliveryErr) {
+				handled.Add(int32(len(deliveryErr.Logs)))
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	client.Info(context.Background(), "in flight", nil)
+	<-received // the background flush is now waiting on the server
+
+	start := time.Now()
+	err = client.Close()
+	elapsed := time.Since(start)
+
+	var deliveryErr *DeliveryError
+	if !errors.As(err, &deliveryErr) || len(deliveryErr.Logs) != 1 {
+		t.Errorf("Close() error = %v, want a *DeliveryError with the in-flight log", err)
+	}
+	if handled.Load() != 1 {
+		t.Errorf("error handler received %d undelivered logs, want 1", handled.Load())
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("Close() took %v, want about the 500ms close flush timeout", elapsed)
+	}
+}
@@ -0,0 +1,90 @@
+package logtide
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Compression is the format used to compress ingest request bodies.
+type Compression int
+
+const (
+	// CompressionNone sends request bodies uncompressed.
+	CompressionNone Compression = iota
+
+	// CompressionGzip compresses request bodies with gzip.
+	CompressionGzip
+
+	// CompressionZstd compresses request bodies with zstd. The encoder lives
+	// in a separate module to keep this one free of the dependency; import it
+	// for its side effect to make the format available:
+	//
+	//	import _ "github.com/logtide-dev/logtide-sdk-go/zstd"
+	CompressionZstd
+)
+
+// String returns the Content-Encoding token of the format, or "none".
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("Compression(%d)", int(c))
+	}
+}
+
+// compressors holds the writer constructor of each available format.
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[Compression]func(io.Writer) (io.WriteCloser, error){
+		CompressionGzip: func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		},
+	}
+)
+
+// RegisterCompressor makes format available to WithCompression, with
+// newWriter returning a writer that compresses into w. It is meant to be
+// called from the init function of the package providing the encoder, and
+// replaces any compressor already registered for format.
+func RegisterCompressor(format Compression, newWriter func(w io.Writer) (io.WriteCloser, error)) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	compressors[format] = newWriter
+}
+
+// compressor returns the writer constructor registered for format.
+func compressor(format Compression) (func(io.Writer) (io.WriteCloser, error), bool) {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+	newWriter, ok := compressors[format]
+	return newWriter, ok
+}
+
+// compress returns body compressed with format.
+func compress(format Compression, body []byte) ([]byte, error) {
+	newWriter, ok := compressor(format)
+	if !ok {
+		return nil, fmt.Errorf("no compressor registered for %v", format)
+	}
+
+	var buf bytes.Buffer
+	zw, err := newWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
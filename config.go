@@ -1,6 +1,15 @@
 package logtide
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
 
 // Config holds the configuration for the LogTide client.
 type Config struct {
@@ -14,7 +23,76 @@ type Config struct {
 	// Service is the default service name for all logs (required).
 	Service string
 
-	// Timeout is the HTTP request timeout.
+	// ServiceSanitizer normalizes Service in New, before validation (optional).
+	ServiceSanitizer func(string) string
+
+	// DefaultMetadata is attached to every log, below call-site metadata (optional).
+	DefaultMetadata map[string]interface{}
+
+	// DefaultTags are attached to every log, below call-site tags (optional).
+	DefaultTags map[string]string
+
+	// MetadataProviders compute fields for each log, layered in order above
+	// DefaultMetadata and below call-site metadata (optional).
+	MetadataProviders []func(ctx context.Context) map[string]interface{}
+
+	// MetadataMerge controls how call-site metadata is combined with default metadata.
+	// Default: MergeOverride
+	MetadataMerge MergeMode
+
+	// ReservedKeyPolicy controls metadata keys that shadow top-level log fields.
+	// Default: PolicyWarn
+	ReservedKeyPolicy ReservedKeyPolicy
+
+	// MetadataSchema rejects logs whose metadata violates it (optional).
+	MetadataSchema MetadataSchema
+
+	// Repanic makes RecoverAndLog panic again after logging a panic.
+	// Default: true
+	Repanic bool
+
+	// BatchTransform rewrites each batch before it is sent (optional).
+	BatchTransform func(ctx context.Context, logs []Log) ([]Log, error)
+
+	// DeadLetter is called with each batch the client gave up delivering to
+	// the ingest API or the configured Sink (optional).
+	DeadLetter func(logs []Log, err error)
+
+	// OnAck is called after each batch the ingest API accepts (optional).
+	OnAck func(received int, serverTime time.Time)
+
+	// OnAuthFailure is called when the ingest API rejects the API key and
+	// shipping is paused (optional).
+	OnAuthFailure func()
+
+	// AuthFailurePolicy controls new logs while shipping is paused after the
+	// API key was rejected.
+	// Default: AuthFailureBuffer
+	AuthFailurePolicy AuthFailurePolicy
+
+	// ErrorHandler is called with errors that cannot be returned to a caller,
+	// such as failed background flushes and validation warnings (optional).
+	ErrorHandler func(error)
+
+	// ErrorHandlerThrottle limits ErrorHandler calls to one per interval for
+	// each kind of error.
+	// Default: 0 (every error is reported)
+	ErrorHandlerThrottle time.Duration
+
+	// ServiceVersion is attached to every log as the "service_version" metadata field (optional).
+	ServiceVersion string
+
+	// Environment is attached to every log as the "environment" metadata field (optional).
+	Environment string
+
+	// UserAgent is an application token prepended to the SDK's User-Agent (optional).
+	UserAgent string
+
+	// HostEnrichment attaches the "host" and "pid" metadata fields to every log.
+	// Default: false
+	HostEnrichment bool
+
+	// Timeout bounds the whole delivery of a batch, including retries.
 	// Default: 30 seconds
 	Timeout time.Duration
 
@@ -26,6 +104,202 @@ type Config struct {
 	// Default: 5 seconds
 	FlushInterval time.Duration
 
+	// ManualFlush starts no background flusher, so logs are only sent by
+	// Flush, Close, or the log call that fills a batch.
+	// Default: false
+	ManualFlush bool
+
+	// IdempotencyKeys sends a unique X-Idempotency-Key header with each batch,
+	// reused across its retries, so the server can drop duplicates.
+	// Default: true
+	IdempotencyKeys bool
+
+	// RetryOnlyWithIdempotency stops retrying network errors that occur after
+	// a request may have reached the server, when IdempotencyKeys is off.
+	// Default: false
+	RetryOnlyWithIdempotency bool
+
+	// SyncMode sends each log in its own request before the log call returns,
+	// instead of batching.
+	// Default: false
+	SyncMode bool
+
+	// LevelAliases maps alternative level names, such as "warning", to the
+	// level they stand for (optional).
+	LevelAliases map[string]LogLevel
+
+	// CloseFlushTimeout bounds the final flush of Close.
+	// Default: 10s
+	CloseFlushTimeout time.Duration
+
+	// UnhealthyAfter is the number of consecutive failed flushes after which
+	// Client.Healthy reports false.
+	// Default: 5
+	UnhealthyAfter int
+
+	// MaxLogAge is the age beyond which buffered logs are discarded instead of
+	// sent, counted in Stats.Expired.
+	// Default: 0 (logs never expire)
+	MaxLogAge time.Duration
+
+	// FlushTimeout bounds each background flush, and manual flushes whose
+	// context has no deadline.
+	// Default: 30 seconds
+	FlushTimeout time.Duration
+
+	// HighWaterMark is the fraction of BatchSize at which a flush is triggered early.
+	// Default: 0 (flush when BatchSize is reached)
+	HighWaterMark float64
+
+	// SampleRate is the fraction of debug, info, and warn logs that are kept.
+	// Error and critical logs are never sampled out.
+	// Default: 1 (keep everything)
+	SampleRate float64
+
+	// RateLimit is the maximum number of logs accepted per second, with bursts
+	// of up to RateLimitBurst. Logs over the limit are silently discarded.
+	// Default: 0 (no limit)
+	RateLimit      float64
+	RateLimitBurst int
+
+	// Filter, if set, is called with each fully enriched log and discards it
+	// when it returns false (optional).
+	Filter func(Log) bool
+
+	// FlattenSeparator, if set, flattens nested metadata maps and slices into
+	// top-level keys joined with this separator, e.g. "user.id".
+	// Default: "" (metadata is sent nested)
+	FlattenSeparator string
+
+	// Caller attaches the file, line, and function of the logging call to
+	// each log's metadata under "caller".
+	// Default: false
+	Caller bool
+
+	// CallerLevel limits Caller to logs at or above this level.
+	// Default: "" (every log)
+	CallerLevel LogLevel
+
+	// GenerateIDs gives logs without a trace or span ID newly generated ones.
+	// Default: false
+	GenerateIDs bool
+
+	// MaxLogBytes is the maximum serialized size of a single log.
+	// Default: 0 (no limit)
+	MaxLogBytes int
+
+	// MaxFieldValueBytes is the maximum size of a single metadata value.
+	// Default: 0 (no limit)
+	MaxFieldValueBytes int
+
+	// UTCTimestamps converts every log time to UTC before batching.
+	// Default: true
+	UTCTimestamps bool
+
+	// TimeFormat is the time.Format layout used for log timestamps on the wire.
+	// Default: "" (the standard time.Time JSON encoding, RFC 3339 with nanoseconds)
+	TimeFormat string
+
+	// BatchSequenceMetadata adds each batch's sequence number to its batch
+	// metadata as "batch_sequence".
+	// Default: false (sequence numbers are only sent as a header)
+	BatchSequenceMetadata bool
+
+	// MaxPayloadBytes is the largest ingest request body sent, before
+	// compression. Larger batches are split into several requests.
+	// Default: 0 (no limit)
+	MaxPayloadBytes int
+
+	// Compression is the format used to compress ingest request bodies.
+	// Default: CompressionNone
+	Compression Compression
+
+	// BatchMetadata is sent once per ingest request alongside the logs (optional).
+	BatchMetadata map[string]interface{}
+
+	// FlushLevel makes logs at or above this level flush immediately.
+	// Default: "" (no level-triggered flushes)
+	FlushLevel LogLevel
+
+	// MaxQueueSize is the maximum number of logs buffered before backpressure applies.
+	// Default: 0 (unbounded)
+	MaxQueueSize int
+
+	// Backpressure selects what happens to new logs when the queue is full.
+	// Default: BackpressureDrop
+	Backpressure BackpressurePolicy
+
+	// Debug, if set, receives human-readable SDK events such as flushes,
+	// retries, and circuit breaker transitions (optional).
+	Debug io.Writer
+
+	// StdoutEcho prints every log sent to standard output as well.
+	// Default: false
+	StdoutEcho bool
+
+	// StdoutEchoColor colors the level of echoed logs, unless NO_COLOR is set.
+	// Default: false
+	StdoutEchoColor bool
+
+	// PriorityFlush sends the most severe buffered logs first.
+	// Default: false (logs are sent in the order they were added)
+	PriorityFlush bool
+
+	// PerServiceBatching keeps logs of different services in separate batches.
+	// Default: false (a batch may mix services)
+	PerServiceBatching bool
+
+	// TraceAffinityBatching keeps logs of the same trace together in a flush.
+	// Default: false (logs are sent in the order they were added)
+	TraceAffinityBatching bool
+
+	// TimeWindow aligns background flushes to wall-clock multiples of this
+	// duration, replacing FlushInterval, and keeps logs of different windows
+	// in separate batches.
+	// Default: 0 (flush every FlushInterval)
+	TimeWindow time.Duration
+
+	// RingBuffer is the capacity of a fixed-size buffer that evicts the oldest
+	// logs when full, replacing MaxQueueSize and Backpressure.
+	// Default: 0 (disabled)
+	RingBuffer int
+
+	// FlushConcurrency is the number of background flushes delivered in parallel.
+	// Default: 0 (one at a time)
+	FlushConcurrency int
+
+	// MaxInFlight is the maximum number of batches being sent at once.
+	// Default: 0 (unlimited)
+	MaxInFlight int
+
+	// RequestInterceptor is called with each ingest request just before it is
+	// sent (optional).
+	RequestInterceptor func(*http.Request) error
+
+	// ResponseInterceptor is called with each ingest response before its body
+	// is decoded (optional).
+	ResponseInterceptor func(*http.Response)
+
+	// Streaming sends logs over one long-lived NDJSON request to the stream
+	// endpoint instead of one request per batch.
+	// Default: false
+	Streaming bool
+
+	// OTLP sends batches in the OTLP/HTTP logs JSON format to the /v1/logs
+	// path of BaseURL instead of the LogTide ingest API.
+	// Default: false
+	OTLP bool
+
+	// Sink overrides where batches are delivered. When set, the API key and
+	// base URL are not required.
+	// Default: nil (the LogTide ingest API)
+	Sink Sink
+
+	// DeliveryMode selects between retrying failed batches and never sending
+	// a batch twice.
+	// Default: AtLeastOnce
+	DeliveryMode DeliveryMode
+
 	// RetryConfig holds the retry configuration.
 	RetryConfig *RetryConfig
 
@@ -33,6 +307,13 @@ type Config struct {
 	CircuitBreakerConfig *CircuitBreakerConfig
 }
 
+// Environment variables read by WithEnv.
+const (
+	EnvAPIKey  = "LOGTIDE_API_KEY"
+	EnvService = "LOGTIDE_SERVICE"
+	EnvBaseURL = "LOGTIDE_BASE_URL"
+)
+
 // Option is a functional option for configuring the Client.
 type Option func(*Config)
 
@@ -42,7 +323,14 @@ func DefaultConfig() *Config {
 		BaseURL:              "https://api.logtide.dev",
 		Timeout:              30 * time.Second,
 		BatchSize:            100,
+		Repanic:              true,
+		CloseFlushTimeout:    defaultStopTimeout,
+		UnhealthyAfter:       5,
 		FlushInterval:        5 * time.Second,
+		FlushTimeout:         30 * time.Second,
+		SampleRate:           1,
+		UTCTimestamps:        true,
+		IdempotencyKeys:      true,
 		RetryConfig:          DefaultRetryConfig(),
 		CircuitBreakerConfig: DefaultCircuitBreakerConfig(),
 	}
@@ -62,6 +350,23 @@ func WithBaseURL(baseURL string) Option {
 	}
 }
 
+// WithEnv reads the API key, service name, and base URL from the LOGTIDE_API_KEY,
+// LOGTIDE_SERVICE, and LOGTIDE_BASE_URL environment variables. Unset or empty
+// variables are ignored, and options applied after WithEnv take precedence.
+func WithEnv() Option {
+	return func(c *Config) {
+		if v := os.Getenv(EnvAPIKey); v != "" {
+			c.APIKey = v
+		}
+		if v := os.Getenv(EnvService); v != "" {
+			c.Service = v
+		}
+		if v := os.Getenv(EnvBaseURL); v != "" {
+			c.BaseURL = v
+		}
+	}
+}
+
 // WithService sets the default service name.
 func WithService(service string) Option {
 	return func(c *Config) {
@@ -69,13 +374,256 @@ func WithService(service string) Option {
 	}
 }
 
-// WithTimeout sets the HTTP timeout.
+// WithServiceSanitizer normalizes the configured service name with sanitize
+// in New, before validation, for names derived from sources such as
+// environment variables that may contain characters the backend rejects.
+// SanitizeServiceName is a ready-made sanitizer:
+//
+//	logtide.WithServiceSanitizer(logtide.SanitizeServiceName)
+func WithServiceSanitizer(sanitize func(string) string) Option {
+	return func(c *Config) {
+		c.ServiceSanitizer = sanitize
+	}
+}
+
+// WithDefaultMetadata attaches the given fields to every log. Keys set in
+// call-site metadata take precedence, see WithMetadataMerge.
+func WithDefaultMetadata(metadata map[string]interface{}) Option {
+	return func(c *Config) {
+		c.DefaultMetadata = metadata
+	}
+}
+
+// WithDefaultTags attaches the given indexed tags to every log. Tags set on a
+// log passed to Emit take precedence. Unlike default metadata, tags are meant
+// for a few low-cardinality labels such as region or tier; keys and values
+// must be 64 characters or less.
+func WithDefaultTags(tags map[string]string) Option {
+	return func(c *Config) {
+		c.DefaultTags = tags
+	}
+}
+
+// WithMetadataProvider adds a function computing fields for each log from its
+// context, for enrichment that should reflect the moment of logging, such as
+// the goroutine count or memory stats. Providers run in the order they were
+// added, after sampling and before the log is enqueued, so sampled-out logs
+// cost nothing; but they run synchronously on every other log, so an
+// expensive provider slows down every logging call. Their fields are layered
+// above default metadata and below call-site metadata, which wins on
+// conflicting keys.
+func WithMetadataProvider(provider func(ctx context.Context) map[string]interface{}) Option {
+	return func(c *Config) {
+		c.MetadataProviders = append(c.MetadataProviders, provider)
+	}
+}
+
+// WithMetadataMerge sets how call-site metadata is combined with default
+// metadata when both contain the same key.
+func WithMetadataMerge(mode MergeMode) Option {
+	return func(c *Config) {
+		c.MetadataMerge = mode
+	}
+}
+
+// WithReservedKeyPolicy sets how metadata keys that shadow top-level log
+// fields (time, service, level, message, trace_id, span_id) are handled.
+func WithReservedKeyPolicy(policy ReservedKeyPolicy) Option {
+	return func(c *Config) {
+		c.ReservedKeyPolicy = policy
+	}
+}
+
+// WithMetadataSchema rejects logs whose metadata, after default metadata is
+// merged in, violates schema: the log is dropped and the logging call returns
+// a ValidationError naming the offending key. Fields the client adds itself,
+// such as the caller or trace IDs, are not checked.
+func WithMetadataSchema(schema MetadataSchema) Option {
+	return func(c *Config) {
+		c.MetadataSchema = schema
+	}
+}
+
+// WithOnAuthFailure sets a function called when the ingest API rejects the
+// API key with a 401 or 403, for example after the key was revoked. The
+// client then stops sending logs in the background instead of failing every
+// batch: the rejected batch goes to the dead-letter callback, and new logs
+// are buffered or dropped according to WithAuthFailurePolicy. fn is called
+// once per rejection, from a flush goroutine; shipping resumes after
+// Client.SetAPIKey installs a new key.
+func WithOnAuthFailure(fn func()) Option {
+	return func(c *Config) {
+		c.OnAuthFailure = fn
+	}
+}
+
+// WithAuthFailurePolicy sets what happens to new logs while shipping is
+// paused after the API key was rejected. See WithOnAuthFailure.
+func WithAuthFailurePolicy(policy AuthFailurePolicy) Option {
+	return func(c *Config) {
+		c.AuthFailurePolicy = policy
+	}
+}
+
+// WithErrorHandler sets a function called with errors that cannot be returned
+// to a caller, such as failed background flushes. It must be safe for
+// concurrent use and should not block.
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *Config) {
+		c.ErrorHandler = handler
+	}
+}
+
+// WithErrorHandlerThrottle calls the error handler at most once per interval
+// for each kind of error, so a backend outage does not turn into a storm of
+// identical reports. Errors are grouped by the type of their innermost cause,
+// refined by its HTTP status code or sentinel error, but not by its message.
+// The first report of a kind after its window is a *ThrottledError counting
+// the errors suppressed in between; errors suppressed after the last report
+// are not reported on their own.
+func WithErrorHandlerThrottle(interval time.Duration) Option {
+	return func(c *Config) {
+		c.ErrorHandlerThrottle = interval
+	}
+}
+
+// WithRepanic controls whether RecoverAndLog panics again with the recovered
+// value after logging it. It is on by default, so recovering only adds a log
+// and does not hide the crash; turn it off to keep a goroutine's panic from
+// taking down the process.
+func WithRepanic(enabled bool) Option {
+	return func(c *Config) {
+		c.Repanic = enabled
+	}
+}
+
+// WithBatchTransform passes each batch through fn before it is validated and
+// sent, for batch-level changes such as reordering, attaching an aggregate
+// checksum, or dropping logs. fn may modify logs in place and returns the
+// logs to send; an empty result sends nothing. An error aborts the send: the
+// batch is dead-lettered and the error reported like a failed flush. fn must
+// be safe for concurrent use.
+func WithBatchTransform(fn func(ctx context.Context, logs []Log) ([]Log, error)) Option {
+	return func(c *Config) {
+		c.BatchTransform = fn
+	}
+}
+
+// WithDeadLetter calls fn with every batch the client gives up delivering to
+// the ingest API, along with the final error: once retries are exhausted,
+// on a non-retryable response such as a 400, when the circuit breaker is
+// open, or for logs too large for WithMaxPayloadBytes. Failed attempts that
+// a retry recovers from are not reported. With WithSink, every batch the sink
+// fails to send is reported instead. fn may keep logs, for example to
+// persist them for later replay, and must be safe for concurrent use.
+func WithDeadLetter(fn func(logs []Log, err error)) Option {
+	return func(c *Config) {
+		c.DeadLetter = fn
+	}
+}
+
+// WithOnAck calls fn after each batch accepted by the ingest API, with the
+// number of logs the server received and the server's timestamp for the
+// batch, e.g. to detect clock skew. A timestamp that cannot be parsed is
+// reported to the error handler and passed as the zero time. fn runs on the
+// flushing goroutine, so it should return quickly. It is not called when
+// streaming or when a custom sink is used.
+func WithOnAck(fn func(received int, serverTime time.Time)) Option {
+	return func(c *Config) {
+		c.OnAck = fn
+	}
+}
+
+// WithServiceVersion attaches a service_version field to every log.
+// A service_version set in call-site metadata takes precedence.
+func WithServiceVersion(version string) Option {
+	return func(c *Config) {
+		c.ServiceVersion = version
+	}
+}
+
+// WithEnvironment attaches an environment field to every log.
+// An environment set in call-site metadata takes precedence.
+func WithEnvironment(environment string) Option {
+	return func(c *Config) {
+		c.Environment = environment
+	}
+}
+
+// WithUserAgent identifies the application in the User-Agent header, e.g.
+// "billing-api/2.1". The SDK's own version token is always kept.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Config) {
+		c.UserAgent = userAgent
+	}
+}
+
+// WithHostEnrichment attaches the hostname and process ID to every log.
+// Both are computed once when the client is created; call-site metadata wins.
+func WithHostEnrichment(enabled bool) Option {
+	return func(c *Config) {
+		c.HostEnrichment = enabled
+	}
+}
+
+// WithTimeout sets the overall timeout for delivering a batch, including retries.
 func WithTimeout(timeout time.Duration) Option {
 	return func(c *Config) {
 		c.Timeout = timeout
 	}
 }
 
+// WithPerAttemptTimeout bounds each individual send attempt, so a slow attempt
+// can be abandoned and retried within the overall timeout.
+func WithPerAttemptTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.RetryConfig = retryConfig(c)
+		c.RetryConfig.PerAttemptTimeout = timeout
+	}
+}
+
+// WithMaxRetryElapsed bounds the total time spent sending a batch, including
+// backoffs: no retry is scheduled whose backoff would end after maxElapsed,
+// even if WithRetry allows more attempts. Zero, the default, means no limit.
+func WithMaxRetryElapsed(maxElapsed time.Duration) Option {
+	return func(c *Config) {
+		c.RetryConfig = retryConfig(c)
+		c.RetryConfig.MaxElapsed = maxElapsed
+	}
+}
+
+// WithRetryableStatusCodes retries responses with the given status codes in
+// addition to the default 429, 500, 502, 503, and 504, e.g. 520 from a
+// Cloudflare gateway or 409 from a backend whose conflicts are transient.
+// Other codes keep their default behavior.
+func WithRetryableStatusCodes(codes ...int) Option {
+	return func(c *Config) {
+		c.RetryConfig = retryConfig(c)
+		c.RetryConfig.RetryableStatusCodes = append([]int(nil), codes...)
+	}
+}
+
+// WithRetryPredicate replaces the default decision of which failed attempts
+// are retried, including WithRetryableStatusCodes, with fn. It is called with
+// the response, or with the error and a nil response, e.g. to give up on DNS
+// failures that will not recover in time while still retrying timeouts.
+func WithRetryPredicate(fn func(resp *http.Response, err error) bool) Option {
+	return func(c *Config) {
+		c.RetryConfig = retryConfig(c)
+		c.RetryConfig.Predicate = fn
+	}
+}
+
+// WithManualFlush stops the client from starting a background flusher, for
+// environments that drive flushing from their own loop. Logs are then only
+// sent by Flush and its variants, by Close, or synchronously by the log call
+// that fills a batch or the queue; FlushInterval is ignored.
+func WithManualFlush(enabled bool) Option {
+	return func(c *Config) {
+		c.ManualFlush = enabled
+	}
+}
+
 // WithBatchSize sets the maximum batch size.
 func WithBatchSize(size int) Option {
 	return func(c *Config) {
@@ -90,17 +638,509 @@ func WithFlushInterval(interval time.Duration) Option {
 	}
 }
 
+// WithSyncMode sends each log in its own request, with the usual retries and
+// circuit breaker, before the log call returns, and returns the delivery
+// error from the call. Nothing is buffered, so no log is lost when a CLI or
+// short-lived script exits, at the cost of one request per log. Close has
+// nothing left to flush.
+func WithSyncMode(enabled bool) Option {
+	return func(c *Config) {
+		c.SyncMode = enabled
+	}
+}
+
+// WithLevelAlias accepts alias, matched case insensitively, wherever a log
+// level is expected and replaces it with level, e.g. for code migrating from
+// a logger with "warning", "fatal", or "trace" levels:
+//
+//	logtide.WithLevelAlias("warning", logtide.LogLevelWarn),
+//	logtide.WithLevelAlias("fatal", logtide.LogLevelCritical),
+//
+// level must be one of the LogLevel constants. Aliases only apply to the
+// client's own logs; use RegisterLevelAlias for aliases that also apply when
+// a LogLevel is decoded from JSON.
+func WithLevelAlias(alias string, level LogLevel) Option {
+	return func(c *Config) {
+		if c.LevelAliases == nil {
+			c.LevelAliases = make(map[string]LogLevel)
+		}
+		c.LevelAliases[alias] = level
+	}
+}
+
+// WithCloseFlushTimeout bounds how long Close spends flushing the logs still
+// buffered or in flight in a background flush, for example shorter in
+// serverless functions that must finish before the invocation ends, or longer
+// in batch jobs with large backlogs. Logs left undelivered are handed to the
+// error handler as described on CloseContext.
+func WithCloseFlushTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.CloseFlushTimeout = timeout
+	}
+}
+
+// WithUnhealthyAfter sets how many flushes in a row must fail before
+// Client.Healthy reports false. Zero makes Healthy ignore flush failures and
+// only report whether the client's background flusher is running.
+func WithUnhealthyAfter(failures int) Option {
+	return func(c *Config) {
+		c.UnhealthyAfter = failures
+	}
+}
+
+// WithMaxLogAge discards logs whose Time is more than maxAge in the past when
+// their batch is flushed, so logs buffered through a long outage do not flood
+// the backend with stale noise once it recovers. Discarded logs are counted
+// in Stats.Expired.
+func WithMaxLogAge(maxAge time.Duration) Option {
+	return func(c *Config) {
+		c.MaxLogAge = maxAge
+	}
+}
+
+// WithFlushTimeout bounds each background flush, so a hung server cannot block
+// the flusher and stall every later flush. A timed-out batch is reported to
+// the error handler. It also bounds Flush, FlushN, FlushAndWait, and
+// FlushScope when their context has no deadline, so a call with
+// context.Background() cannot hang; a deadline set by the caller is used
+// as is. Zero disables the limit.
+func WithFlushTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.FlushTimeout = timeout
+	}
+}
+
+// WithHighWaterMark triggers a flush once the buffer reaches the given
+// fraction of the batch size, e.g. 0.8 flushes at 80 logs for a batch size of 100.
+func WithHighWaterMark(fraction float64) Option {
+	return func(c *Config) {
+		c.HighWaterMark = fraction
+	}
+}
+
+// WithSampling keeps only the given fraction (0 to 1) of debug, info, and warn
+// logs; the rest are silently discarded. Error and critical logs are always
+// kept, as are logs from a context marked with ContextForceKeep.
+func WithSampling(rate float64) Option {
+	return func(c *Config) {
+		c.SampleRate = rate
+	}
+}
+
+// WithRateLimit accepts at most perSecond logs per second, allowing bursts of
+// up to burst logs; the rest are silently discarded. A burst below 1 is
+// treated as 1. Logs from a context marked with ContextForceKeep are always
+// accepted and do not use up the limit.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(c *Config) {
+		c.RateLimit = perSecond
+		c.RateLimitBurst = burst
+	}
+}
+
+// WithFilter discards logs for which keep returns false. keep sees the log
+// after defaults, default metadata, and context enrichment have been applied,
+// and must not modify its metadata.
+func WithFilter(keep func(Log) bool) Option {
+	return func(c *Config) {
+		c.Filter = keep
+	}
+}
+
+// WithFlattenMetadata flattens nested metadata into top-level keys before
+// sending, for backends that only index top-level fields: {"user": {"id": 1}}
+// is sent as {"user.id": 1}, and slice elements are keyed by index, as in
+// "tags.0". An empty separator means ".". The caller's maps are not modified.
+func WithFlattenMetadata(separator string) Option {
+	return func(c *Config) {
+		if separator == "" {
+			separator = "."
+		}
+		c.FlattenSeparator = separator
+	}
+}
+
+// WithCaller attaches the source location of the logging call to each log's
+// metadata under "caller", as a map with "function", "file", and "line", so
+// logs can be traced back to the code that emitted them. Walking the stack
+// adds noticeable overhead to every log, so it is off by default. Call-site
+// metadata with a "caller" key is kept.
+func WithCaller(enabled bool) Option {
+	return func(c *Config) {
+		c.Caller = enabled
+	}
+}
+
+// WithCallerForLevel attaches the source location like WithCaller, but only
+// to logs at or above level, so that errors can be traced to their source
+// while frequent lower-level logs skip the cost of walking the stack.
+func WithCallerForLevel(level LogLevel) Option {
+	return func(c *Config) {
+		c.Caller = true
+		c.CallerLevel = level
+	}
+}
+
+// WithGenerateIDs generates a random trace ID and span ID for every log that
+// has neither an OpenTelemetry span nor IDs from ContextWithGeneratedIDs in
+// its context. Use ContextWithGeneratedIDs to share one pair across the logs
+// of a request.
+func WithGenerateIDs(enabled bool) Option {
+	return func(c *Config) {
+		c.GenerateIDs = enabled
+	}
+}
+
+// WithMaxLogBytes rejects logs whose JSON encoding exceeds maxBytes with an
+// error wrapping ErrLogTooLarge, so callers can truncate or drop them.
+func WithMaxLogBytes(maxBytes int) Option {
+	return func(c *Config) {
+		c.MaxLogBytes = maxBytes
+	}
+}
+
+// WithMaxFieldValueBytes truncates string metadata values longer than maxBytes,
+// at any nesting depth, and replaces other values whose JSON encoding exceeds
+// maxBytes with a placeholder noting their size. The log is still sent.
+func WithMaxFieldValueBytes(maxBytes int) Option {
+	return func(c *Config) {
+		c.MaxFieldValueBytes = maxBytes
+	}
+}
+
+// WithUTCTimestamps controls whether log times, including caller-supplied
+// ones, are converted to UTC before batching. It is on by default so logs from
+// servers in different time zones carry consistent offsets.
+func WithUTCTimestamps(enabled bool) Option {
+	return func(c *Config) {
+		c.UTCTimestamps = enabled
+	}
+}
+
+// TimePrecision is the precision of log timestamps on the wire, set with
+// WithTimePrecision.
+type TimePrecision int
+
+const (
+	// PrecisionNanos sends timestamps in RFC 3339 with up to nine fractional
+	// digits (time.RFC3339Nano), so events within the same millisecond keep
+	// their order. It is the default.
+	PrecisionNanos TimePrecision = iota
+
+	// PrecisionMillis sends timestamps in RFC 3339 with exactly three
+	// fractional digits, for backends that reject finer precision.
+	PrecisionMillis
+)
+
+// millisTimeFormat is the wire layout used by PrecisionMillis.
+const millisTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// WithTimePrecision selects the precision of log timestamps on the wire. It
+// sets the same wire layout as WithTimeFormat, so whichever of the two is
+// applied last wins.
+func WithTimePrecision(precision TimePrecision) Option {
+	return func(c *Config) {
+		switch precision {
+		case PrecisionNanos:
+			c.TimeFormat = ""
+		case PrecisionMillis:
+			c.TimeFormat = millisTimeFormat
+		}
+	}
+}
+
+// WithTimeFormat sends log timestamps formatted with layout (see time.Format),
+// for backends that expect a specific representation such as time.RFC3339.
+func WithTimeFormat(layout string) Option {
+	return func(c *Config) {
+		c.TimeFormat = layout
+	}
+}
+
+// WithBatchSequenceMetadata also sends each batch's sequence number, always
+// sent in the X-Batch-Sequence header, as the "batch_sequence" batch metadata
+// field. Sequence numbers start at 1, increase by one for every batch the
+// client sends, and stay the same when a batch is retried, so the server can
+// detect lost batches.
+func WithBatchSequenceMetadata(enabled bool) Option {
+	return func(c *Config) {
+		c.BatchSequenceMetadata = enabled
+	}
+}
+
+// WithMaxPayloadBytes splits batches whose ingest request would exceed n
+// bytes, before compression, into several requests, for servers that reject
+// large bodies. Unlike the batch size, it does not trigger flushes: it is a
+// safety split applied when a batch is sent. A log too large to fit in any
+// request is not sent, and the flush fails with an error wrapping
+// ErrLogTooLarge.
+func WithMaxPayloadBytes(n int) Option {
+	return func(c *Config) {
+		c.MaxPayloadBytes = n
+	}
+}
+
+// WithCompression compresses ingest request bodies with format and sets the
+// matching Content-Encoding header. CompressionZstd requires importing the
+// github.com/logtide-dev/logtide-sdk-go/zstd package. Streaming connections
+// are not compressed.
+func WithCompression(format Compression) Option {
+	return func(c *Config) {
+		c.Compression = format
+	}
+}
+
+// WithBatchMetadata sets attributes sent once per batch in the ingest request,
+// such as a producer instance ID, instead of repeating them on every log.
+func WithBatchMetadata(metadata map[string]interface{}) Option {
+	return func(c *Config) {
+		c.BatchMetadata = metadata
+	}
+}
+
+// WithFlushOnLevel flushes immediately after a log at or above level is added,
+// e.g. LogLevelError for prompt alerting, instead of waiting for the interval.
+func WithFlushOnLevel(level LogLevel) Option {
+	return func(c *Config) {
+		c.FlushLevel = level
+	}
+}
+
+// WithMaxQueueSize sets the maximum number of buffered logs.
+func WithMaxQueueSize(size int) Option {
+	return func(c *Config) {
+		c.MaxQueueSize = size
+	}
+}
+
+// WithBackpressure sets the policy applied when the queue is full.
+func WithBackpressure(policy BackpressurePolicy) Option {
+	return func(c *Config) {
+		c.Backpressure = policy
+	}
+}
+
+// WithStdoutEcho prints each log to standard output as a readable line, with
+// its metadata as key=value pairs, in addition to shipping it, for watching
+// logs during local development. If colorized is set, levels are colored by
+// severity, unless the NO_COLOR environment variable is set.
+func WithStdoutEcho(colorized bool) Option {
+	return func(c *Config) {
+		c.StdoutEcho = true
+		c.StdoutEchoColor = colorized
+	}
+}
+
+// WithDebug writes internal SDK events (batch flushed, retry scheduled, circuit
+// opened) to w, for diagnosing why logs are not arriving. Unlike the error
+// handler it is meant for interactive use; pass os.Stderr to see events in a
+// terminal.
+func WithDebug(w io.Writer) Option {
+	return func(c *Config) {
+		c.Debug = w
+	}
+}
+
+// WithPriorityFlush orders each flush by severity, so that when the queue is
+// backed up a critical log is sent in the first batch instead of waiting
+// behind thousands of debug logs. Logs of the same level keep their order.
+func WithPriorityFlush(enabled bool) Option {
+	return func(c *Config) {
+		c.PriorityFlush = enabled
+	}
+}
+
+// WithPerServiceBatching sends the logs of each service in batches of their
+// own, for backends that require every ingest request to hold a single
+// service when logs override it per call or through ContextWithService. Each
+// flush, including those of Flush and Close, sends every service's logs.
+func WithPerServiceBatching(enabled bool) Option {
+	return func(c *Config) {
+		c.PerServiceBatching = enabled
+	}
+}
+
+// WithTraceAffinityBatching reorders each flush so logs sharing a TraceID are
+// sent together, in the same batch unless the trace has more logs than
+// BatchSize, for backends that process logs per trace. Batches may then hold
+// fewer than BatchSize logs. Logs are never held back for their trace: a
+// trace whose logs arrive across flushes, which happen at least every
+// FlushInterval, is split between them.
+func WithTraceAffinityBatching(enabled bool) Option {
+	return func(c *Config) {
+		c.TraceAffinityBatching = enabled
+	}
+}
+
+// WithTimeWindowBatching groups logs into fixed windows of log time, such as
+// per-second buckets, for downstream aggregation: background flushes happen
+// at every wall-clock multiple of window instead of every FlushInterval, and
+// a batch never holds logs of two windows. Size- and level-triggered flushes
+// still happen between edges, so a busy window may span several batches.
+func WithTimeWindowBatching(window time.Duration) Option {
+	return func(c *Config) {
+		c.TimeWindow = window
+	}
+}
+
+// WithRingBuffer buffers at most capacity logs in a fixed-size ring that
+// overwrites the oldest log when full, for deployments that prefer losing old
+// logs to growing memory or blocking. Add never blocks, and evicted logs are
+// counted in Stats.Dropped. Logs are still flushed at BatchSize and on the
+// flush interval.
+func WithRingBuffer(capacity int) Option {
+	return func(c *Config) {
+		c.RingBuffer = capacity
+	}
+}
+
+// WithFlushConcurrency delivers background flushes with a pool of n workers,
+// so a slow network does not limit throughput to one batch at a time. Logs
+// within a batch keep their order, but batches may arrive out of order.
+// Close waits for every worker to finish.
+func WithFlushConcurrency(n int) Option {
+	return func(c *Config) {
+		c.FlushConcurrency = n
+	}
+}
+
+// WithMaxInFlight limits how many batches are sent at once, across flush
+// workers and Flush calls, so concurrent flushing cannot overwhelm the
+// network. While all n slots are busy further flushes wait, logs accumulate
+// in the buffer, and once MaxQueueSize is reached new logs are handled by the
+// Backpressure policy.
+func WithMaxInFlight(n int) Option {
+	return func(c *Config) {
+		c.MaxInFlight = n
+	}
+}
+
+// WithRequestInterceptor calls fn with each ingest request after its headers
+// are set and before it is sent, for example to sign the request for a
+// gateway. fn may read the body through req.GetBody and may change headers.
+// Returning an error aborts the send without retrying.
+func WithRequestInterceptor(fn func(req *http.Request) error) Option {
+	return func(c *Config) {
+		c.RequestInterceptor = fn
+	}
+}
+
+// WithResponseInterceptor calls fn with every ingest response, including
+// those of attempts that will be retried, before the body is decoded. fn may
+// inspect the status and headers freely. If it reads resp.Body it must replace
+// it with a reader producing the same bytes, or decoding the response fails.
+func WithResponseInterceptor(fn func(resp *http.Response)) Option {
+	return func(c *Config) {
+		c.ResponseInterceptor = fn
+	}
+}
+
+// WithStreaming keeps one HTTP request to the LogTide stream endpoint open and
+// writes each flushed batch to it as newline-delimited JSON, avoiding the
+// per-batch request overhead at very high volume. A broken stream is reopened
+// with the retry backoff, and Close ends the stream. The circuit breaker and
+// per-request timeouts do not apply to streaming. Frames carry single logs,
+// so batch metadata cannot be sent and is rejected by New.
+func WithStreaming(enabled bool) Option {
+	return func(c *Config) {
+		c.Streaming = enabled
+	}
+}
+
+// WithOTLP sends each batch as an OTLP/HTTP logs request in the JSON
+// encoding to BaseURL + "/v1/logs", so any OpenTelemetry-compatible
+// collector can receive the logs, e.g. with WithBaseURL("http://collector:4318").
+// Each service becomes a resource with a service.name attribute, levels map
+// to OTLP severities, the message becomes the record body, and metadata and
+// tags become record attributes. Retries, the circuit breaker, compression,
+// and interceptors apply as for the ingest API; the API key is optional and
+// only sent if set. It cannot be combined with streaming or
+// WithMaxPayloadBytes.
+func WithOTLP(enabled bool) Option {
+	return func(c *Config) {
+		c.OTLP = enabled
+	}
+}
+
+// WithSink delivers batches to the given sink instead of the LogTide API,
+// e.g. a WriterSink for local development.
+func WithSink(sink Sink) Option {
+	return func(c *Config) {
+		c.Sink = sink
+	}
+}
+
 // WithRetry sets the retry configuration.
 func WithRetry(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
 	return func(c *Config) {
-		c.RetryConfig = &RetryConfig{
-			MaxRetries: maxRetries,
-			MinBackoff: minBackoff,
-			MaxBackoff: maxBackoff,
-		}
+		c.RetryConfig = retryConfig(c)
+		c.RetryConfig.MaxRetries = maxRetries
+		c.RetryConfig.MinBackoff = minBackoff
+		c.RetryConfig.MaxBackoff = maxBackoff
+	}
+}
+
+// WithDeliveryMode states the delivery guarantee the client aims for.
+// AtMostOnce disables retries, overriding WithRetry, so a batch is never sent
+// twice. AtLeastOnce, the default, keeps the configured retries and relies on
+// idempotency keys (see WithIdempotencyKeys) to avoid duplicates.
+func WithDeliveryMode(mode DeliveryMode) Option {
+	return func(c *Config) {
+		c.DeliveryMode = mode
+	}
+}
+
+// WithRetryBudget caps retries shared across all batches to ratio times the
+// number of successful requests plus minPerSec retries per second. Once the
+// budget is spent, failed batches are not retried.
+func WithRetryBudget(ratio float64, minPerSec int) Option {
+	return func(c *Config) {
+		c.RetryConfig = retryConfig(c)
+		c.RetryConfig.Budget = NewRetryBudget(ratio, minPerSec)
+	}
+}
+
+// WithRetryJitter sets the source of backoff jitter, a function returning
+// values in [0, 1), e.g. the Float64 method of a seeded *rand.Rand guarded by
+// a mutex, to make retry timing reproducible in tests.
+func WithRetryJitter(jitter func() float64) Option {
+	return func(c *Config) {
+		c.RetryConfig = retryConfig(c)
+		c.RetryConfig.Jitter = jitter
+	}
+}
+
+// WithIdempotencyKeys sets whether each batch is sent with a unique
+// X-Idempotency-Key header, reused across its retries, so the server can drop
+// the duplicate when a batch was stored but the response was lost. Keys are
+// sent by default; turn them off for backends that reject unknown headers.
+func WithIdempotencyKeys(enabled bool) Option {
+	return func(c *Config) {
+		c.IdempotencyKeys = enabled
+	}
+}
+
+// WithRetryOnlyWithIdempotency avoids duplicate logs when idempotency keys are
+// turned off: a network error is then only retried if it occurred before the
+// request was written, such as a refused connection, since a request that
+// may have reached the server could be stored twice. HTTP error responses
+// are still retried as usual. It has no effect while idempotency keys are on.
+func WithRetryOnlyWithIdempotency(enabled bool) Option {
+	return func(c *Config) {
+		c.RetryOnlyWithIdempotency = enabled
 	}
 }
 
+// retryConfig returns a copy of the config's retry settings that options can
+// modify without affecting other clients.
+func retryConfig(c *Config) *RetryConfig {
+	if c.RetryConfig == nil {
+		return DefaultRetryConfig()
+	}
+	rc := *c.RetryConfig
+	return &rc
+}
+
 // WithCircuitBreaker sets the circuit breaker configuration.
 func WithCircuitBreaker(failureThreshold int, timeout time.Duration) Option {
 	return func(c *Config) {
@@ -111,19 +1151,204 @@ func WithCircuitBreaker(failureThreshold int, timeout time.Duration) Option {
 	}
 }
 
+// snapshot returns a copy of c whose maps and sub-configs can be modified
+// without affecting c, with the API key redacted to its last four characters.
+func (c *Config) snapshot() Config {
+	cp := *c
+	cp.APIKey = redactAPIKey(c.APIKey)
+	cp.DefaultMetadata = copyMetadata(c.DefaultMetadata)
+	cp.BatchMetadata = copyMetadata(c.BatchMetadata)
+	if c.DefaultTags != nil {
+		cp.DefaultTags = make(map[string]string, len(c.DefaultTags))
+		for k, v := range c.DefaultTags {
+			cp.DefaultTags[k] = v
+		}
+	}
+	if c.LevelAliases != nil {
+		cp.LevelAliases = make(map[string]LogLevel, len(c.LevelAliases))
+		for alias, level := range c.LevelAliases {
+			cp.LevelAliases[alias] = level
+		}
+	}
+	if c.MetadataSchema != nil {
+		cp.MetadataSchema = make(MetadataSchema, len(c.MetadataSchema))
+		for key, field := range c.MetadataSchema {
+			cp.MetadataSchema[key] = field
+		}
+	}
+	cp.MetadataProviders = append([]func(context.Context) map[string]interface{}(nil), c.MetadataProviders...)
+	if c.RetryConfig != nil {
+		rc := *c.RetryConfig
+		cp.RetryConfig = &rc
+	}
+	if c.CircuitBreakerConfig != nil {
+		cbc := *c.CircuitBreakerConfig
+		cp.CircuitBreakerConfig = &cbc
+	}
+	return cp
+}
+
+// redactAPIKey masks all but the last four characters of key.
+func redactAPIKey(key string) string {
+	if len(key) <= 4 {
+		return strings.Repeat("*", len(key))
+	}
+	return strings.Repeat("*", len(key)-4) + key[len(key)-4:]
+}
+
+// copyMetadata returns a shallow copy of metadata, or nil if it is nil.
+func copyMetadata(metadata map[string]interface{}) map[string]interface{} {
+	if metadata == nil {
+		return nil
+	}
+	cp := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		cp[k] = v
+	}
+	return cp
+}
+
 // validate validates the configuration.
 func (c *Config) validate() error {
-	if c.APIKey == "" {
+	if c.APIKey == "" && c.Sink == nil && !c.OTLP {
 		return ErrInvalidAPIKey
 	}
+	if c.OTLP && (c.Streaming || c.MaxPayloadBytes > 0) {
+		return &ValidationError{Field: "otlp", Message: "OTLP cannot be combined with streaming or a max payload size"}
+	}
+	if c.Streaming && c.Sink != nil {
+		return &ValidationError{Field: "streaming", Message: "streaming cannot be combined with a custom sink"}
+	}
+	if c.Streaming && (len(c.BatchMetadata) > 0 || c.BatchSequenceMetadata) {
+		return &ValidationError{Field: "streaming", Message: "streaming cannot be combined with batch metadata"}
+	}
 	if c.Service == "" {
 		return &ValidationError{Field: "service", Message: "service name is required"}
 	}
 	if len(c.Service) > 100 {
 		return &ValidationError{Field: "service", Message: "service name must be 100 characters or less"}
 	}
-	if c.BaseURL == "" {
+	if c.BaseURL == "" && c.Sink == nil {
 		return &ValidationError{Field: "baseURL", Message: "base URL is required"}
 	}
+	if c.BaseURL != "" {
+		if err := validateBaseURL(c.BaseURL); err != nil {
+			return err
+		}
+	}
+	if c.BatchSize <= 0 {
+		return &ValidationError{Field: "batchSize", Message: "batch size must be positive"}
+	}
+	if c.BatchSize > maxBatchSize {
+		// Each flush is sent as one request, so larger batches would be rejected
+		return &ValidationError{Field: "batchSize", Message: fmt.Sprintf("batch size must be %d or less", maxBatchSize)}
+	}
+	if c.FlushInterval <= 0 {
+		return &ValidationError{Field: "flushInterval", Message: "flush interval must be positive"}
+	}
+	if c.RingBuffer < 0 {
+		return &ValidationError{Field: "ringBuffer", Message: "ring buffer capacity must not be negative"}
+	}
+	if c.Compression != CompressionNone {
+		if _, ok := compressor(c.Compression); !ok {
+			msg := fmt.Sprintf("unsupported compression %v", c.Compression)
+			if c.Compression == CompressionZstd {
+				msg = "zstd compression requires importing github.com/logtide-dev/logtide-sdk-go/zstd"
+			}
+			return &ValidationError{Field: "compression", Message: msg}
+		}
+	}
+	if c.ErrorHandlerThrottle < 0 {
+		return &ValidationError{Field: "errorHandlerThrottle", Message: "error handler throttle must not be negative"}
+	}
+	if c.MaxPayloadBytes < 0 {
+		return &ValidationError{Field: "maxPayloadBytes", Message: "max payload bytes must not be negative"}
+	}
+	for alias, level := range c.LevelAliases {
+		if alias == "" {
+			return &ValidationError{Field: "levelAliases", Message: "level alias must not be empty"}
+		}
+		if !validLogLevels[level] {
+			return &ValidationError{Field: "levelAliases", Message: fmt.Sprintf("alias %q must stand for one of: debug, info, warn, error, critical", alias)}
+		}
+	}
+	if c.CallerLevel != "" && !validLogLevels[c.CallerLevel] {
+		return &ValidationError{Field: "callerLevel", Message: fmt.Sprintf("invalid caller level: %s", c.CallerLevel)}
+	}
+	if err := c.MetadataSchema.validate(); err != nil {
+		return err
+	}
+	if c.AuthFailurePolicy != AuthFailureBuffer && c.AuthFailurePolicy != AuthFailureDrop {
+		return &ValidationError{Field: "authFailurePolicy", Message: fmt.Sprintf("unknown auth failure policy %d", c.AuthFailurePolicy)}
+	}
+	if c.DeliveryMode != AtLeastOnce && c.DeliveryMode != AtMostOnce {
+		return &ValidationError{Field: "deliveryMode", Message: fmt.Sprintf("unknown delivery mode %d", c.DeliveryMode)}
+	}
+	if c.CloseFlushTimeout <= 0 {
+		return &ValidationError{Field: "closeFlushTimeout", Message: "close flush timeout must be positive"}
+	}
+	if c.TimeWindow < 0 {
+		return &ValidationError{Field: "timeWindow", Message: "time window must not be negative"}
+	}
+	if c.UnhealthyAfter < 0 {
+		return &ValidationError{Field: "unhealthyAfter", Message: "unhealthy-after failure count must not be negative"}
+	}
+	if c.MaxLogAge < 0 {
+		return &ValidationError{Field: "maxLogAge", Message: "max log age must not be negative"}
+	}
+	if c.FlushTimeout < 0 {
+		return &ValidationError{Field: "flushTimeout", Message: "flush timeout must not be negative"}
+	}
+	if c.FlushConcurrency < 0 {
+		return &ValidationError{Field: "flushConcurrency", Message: "flush concurrency must not be negative"}
+	}
+	if c.MaxInFlight < 0 {
+		return &ValidationError{Field: "maxInFlight", Message: "max in-flight batches must not be negative"}
+	}
+	if c.ServiceVersion != "" && strings.TrimSpace(c.ServiceVersion) == "" {
+		return &ValidationError{Field: "serviceVersion", Message: "service version must not be blank"}
+	}
+	if len(c.ServiceVersion) > 100 {
+		return &ValidationError{Field: "serviceVersion", Message: "service version must be 100 characters or less"}
+	}
+	if c.Environment != "" && strings.TrimSpace(c.Environment) == "" {
+		return &ValidationError{Field: "environment", Message: "environment must not be blank"}
+	}
+	if len(c.Environment) > 100 {
+		return &ValidationError{Field: "environment", Message: "environment must be 100 characters or less"}
+	}
+	if c.FlushLevel != "" && !validLogLevels[c.FlushLevel] {
+		return &ValidationError{Field: "flushLevel", Message: fmt.Sprintf("invalid log level: %s", c.FlushLevel)}
+	}
+	if err := validateTags(c.DefaultTags); err != nil {
+		return err
+	}
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		return &ValidationError{Field: "sampleRate", Message: "sample rate must be between 0 and 1"}
+	}
+	if c.RateLimit < 0 {
+		return &ValidationError{Field: "rateLimit", Message: "rate limit must not be negative"}
+	}
+	if c.RateLimitBurst < 0 {
+		return &ValidationError{Field: "rateLimitBurst", Message: "rate limit burst must not be negative"}
+	}
+	if c.HighWaterMark < 0 || c.HighWaterMark > 1 {
+		return &ValidationError{Field: "highWaterMark", Message: "high-water mark must be between 0 and 1"}
+	}
+	return nil
+}
+
+// validateBaseURL checks that baseURL is an absolute http or https URL.
+func validateBaseURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return &ValidationError{Field: "baseURL", Message: fmt.Sprintf("invalid base URL %q: %v", baseURL, err)}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return &ValidationError{Field: "baseURL", Message: fmt.Sprintf("base URL %q must start with http:// or https://", baseURL)}
+	}
+	if u.Host == "" {
+		return &ValidationError{Field: "baseURL", Message: fmt.Sprintf("base URL %q has no host", baseURL)}
+	}
 	return nil
 }
@@ -2,10 +2,163 @@ package logtide
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 
 	"go.opentelemetry.io/otel/trace"
 )
 
+// contextKey is the type of context keys defined by this package.
+type contextKey int
+
+const (
+	// forceKeepKey marks a context whose logs bypass sampling and rate limiting.
+	forceKeepKey contextKey = iota
+
+	// generatedIDsKey holds the IDs stored by ContextWithGeneratedIDs.
+	generatedIDsKey
+
+	// serviceKey holds the service name stored by ContextWithService.
+	serviceKey
+
+	// flushScopeKey holds the scope created by ContextWithFlushScope.
+	flushScopeKey
+
+	// sampleRateKey holds the rate stored by ContextWithSampleRate.
+	sampleRateKey
+
+	// callerSkipKey holds the frames to skip stored by ContextWithCallerSkip,
+	// or -1 from ContextWithoutCaller.
+	callerSkipKey
+)
+
+// generatedIDs is a trace and span ID pair generated without OpenTelemetry.
+type generatedIDs struct {
+	traceID string
+	spanID  string
+}
+
+// ContextForceKeep returns a context whose logs are never sampled out, whether
+// by WithSampling or ContextWithSampleRate, and never discarded or counted by
+// WithRateLimit, e.g. for requests belonging to a fully traced transaction.
+// Filters, validation, and a full queue still apply.
+func ContextForceKeep(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceKeepKey, true)
+}
+
+// isForceKeep reports whether logs derived from ctx bypass sampling and rate
+// limiting.
+func isForceKeep(ctx context.Context) bool {
+	keep, _ := ctx.Value(forceKeepKey).(bool)
+	return keep
+}
+
+// ContextWithSampleRate returns a context whose logs are sampled at rate
+// instead of the rate set with WithSampling, e.g. 1 for a request whose trace
+// was sampled at the edge and 0 for one that was not. Rates outside [0, 1]
+// are clamped. Error and critical logs, and logs from a ContextForceKeep
+// context, are still always kept.
+func ContextWithSampleRate(ctx context.Context, rate float64) context.Context {
+	return context.WithValue(ctx, sampleRateKey, min(max(rate, 0), 1))
+}
+
+// sampleRateFromContext returns the rate stored by ContextWithSampleRate, if any.
+func sampleRateFromContext(ctx context.Context) (float64, bool) {
+	rate, ok := ctx.Value(sampleRateKey).(float64)
+	return rate, ok
+}
+
+// ContextWithCallerSkip returns a context whose logs record the caller skip
+// frames further up the stack than the first frame outside the SDK, for
+// logging adapters called through another library, such as logr, whose own
+// frames sit between the SDK and the call site. It only matters with
+// WithCaller.
+func ContextWithCallerSkip(ctx context.Context, skip int) context.Context {
+	return context.WithValue(ctx, callerSkipKey, max(skip, 0))
+}
+
+// ContextWithoutCaller returns a context whose logs never record the caller,
+// for adapters that send logs away from their call site, such as exporters
+// invoked by a background processor.
+func ContextWithoutCaller(ctx context.Context) context.Context {
+	return context.WithValue(ctx, callerSkipKey, -1)
+}
+
+// callerSkipFromContext returns the skip stored by ContextWithCallerSkip, -1
+// if the caller must not be recorded, or 0.
+func callerSkipFromContext(ctx context.Context) int {
+	skip, _ := ctx.Value(callerSkipKey).(int)
+	return skip
+}
+
+// ContextWithService returns a context whose logs are attributed to service
+// instead of the configured default, e.g. for the tenant a gateway request
+// belongs to. A Service set on the log itself still takes precedence. The
+// name is validated like any other service when a log is sent, so an invalid
+// one makes the log call fail with a *ValidationError.
+func ContextWithService(ctx context.Context, service string) context.Context {
+	return context.WithValue(ctx, serviceKey, service)
+}
+
+// serviceFromContext returns the service stored by ContextWithService, if any.
+func serviceFromContext(ctx context.Context) string {
+	service, _ := ctx.Value(serviceKey).(string)
+	return service
+}
+
+// ContextWithFlushScope returns a context that tracks the logs added with it,
+// so that Client.FlushScope can deliver them without waiting for the rest of
+// the buffer. Contexts derived from it share the scope. A typical use is a
+// handler that must not respond before the logs of a critical transaction
+// are sent:
+//
+//	ctx := logtide.ContextWithFlushScope(r.Context())
+//	client.Info(ctx, "payment captured", metadata)
+//	if err := client.FlushScope(ctx); err != nil {
+//		// the request deadline passed or delivery failed
+//	}
+func ContextWithFlushScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, flushScopeKey, newFlushScope())
+}
+
+// flushScopeFromContext returns the scope created by ContextWithFlushScope, if any.
+func flushScopeFromContext(ctx context.Context) *flushScope {
+	scope, _ := ctx.Value(flushScopeKey).(*flushScope)
+	return scope
+}
+
+// ContextWithGeneratedIDs returns a context carrying a newly generated trace
+// ID and span ID, so that logs from it can be correlated when there is no
+// OpenTelemetry span. An OpenTelemetry span in the context still takes
+// precedence.
+func ContextWithGeneratedIDs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, generatedIDsKey, generatedIDs{
+		traceID: newTraceID(),
+		spanID:  newSpanID(),
+	})
+}
+
+// newTraceID returns a random W3C trace ID of 32 hexadecimal characters.
+func newTraceID() string {
+	return randomHex(16)
+}
+
+// newSpanID returns a random W3C span ID of 16 hexadecimal characters.
+func newSpanID() string {
+	return randomHex(8)
+}
+
+// randomHex returns n random bytes, hex encoded. An ID of all zeros is
+// invalid in W3C trace context, so the last byte is never zero.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	if b[n-1] == 0 {
+		b[n-1] = 1
+	}
+	return hex.EncodeToString(b)
+}
+
 // extractTraceID extracts the trace ID from the context if an OpenTelemetry span is present.
 func extractTraceID(ctx context.Context) string {
 	span := trace.SpanFromContext(ctx)
@@ -26,7 +179,9 @@ func extractSpanID(ctx context.Context) string {
 	return span.SpanContext().SpanID().String()
 }
 
-// enrichLogWithContext enriches a log entry with trace and span IDs from the context.
+// enrichLogWithContext enriches a log entry with trace and span IDs from the
+// context, taken from an OpenTelemetry span or else from
+// ContextWithGeneratedIDs.
 func enrichLogWithContext(ctx context.Context, log *Log) {
 	// Only extract if not already set
 	if log.TraceID == "" {
@@ -35,4 +190,23 @@ func enrichLogWithContext(ctx context.Context, log *Log) {
 	if log.SpanID == "" {
 		log.SpanID = extractSpanID(ctx)
 	}
+
+	if ids, ok := ctx.Value(generatedIDsKey).(generatedIDs); ok {
+		if log.TraceID == "" {
+			log.TraceID = ids.traceID
+		}
+		if log.SpanID == "" {
+			log.SpanID = ids.spanID
+		}
+	}
+}
+
+// generateMissingIDs gives a log without trace or span IDs newly generated ones.
+func generateMissingIDs(log *Log) {
+	if log.TraceID == "" {
+		log.TraceID = newTraceID()
+	}
+	if log.SpanID == "" {
+		log.SpanID = newSpanID()
+	}
 }
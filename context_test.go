@@ -2,6 +2,8 @@ package logtide
 
 import (
 	"context"
+	"encoding/hex"
+	"strings"
 	"testing"
 
 	"go.opentelemetry.io/otel/sdk/trace"
@@ -170,3 +172,58 @@ func TestEnrichLogWithContext(t *testing.T) {
 		}
 	})
 }
+
+func TestGeneratedIDs(t *testing.T) {
+	hexOfLength := func(s string, n int) bool {
+		if len(s) != n {
+			return false
+		}
+		_, err := hex.DecodeString(s)
+		return err == nil && strings.Trim(s, "0") != ""
+	}
+
+	t.Run("generates valid IDs", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			log := &Log{Service: "test", Level: LogLevelInfo, Message: "test message"}
+			generateMissingIDs(log)
+
+			if !hexOfLength(log.TraceID, 32) {
+				t.Fatalf("TraceID = %q, want 32 hex characters", log.TraceID)
+			}
+			if !hexOfLength(log.SpanID, 16) {
+				t.Fatalf("SpanID = %q, want 16 hex characters", log.SpanID)
+			}
+			if err := validateLog(log); err != nil {
+				t.Fatalf("validateLog() error = %v", err)
+			}
+		}
+	})
+
+	t.Run("shares IDs across a context", func(t *testing.T) {
+		ctx := ContextWithGeneratedIDs(context.Background())
+
+		first, second := &Log{}, &Log{}
+		enrichLogWithContext(ctx, first)
+		enrichLogWithContext(ctx, second)
+
+		if !hexOfLength(first.TraceID, 32) || !hexOfLength(first.SpanID, 16) {
+			t.Fatalf("IDs = %q, %q, want generated IDs", first.TraceID, first.SpanID)
+		}
+		if second.TraceID != first.TraceID || second.SpanID != first.SpanID {
+			t.Errorf("second log IDs = %q, %q, want %q, %q", second.TraceID, second.SpanID, first.TraceID, first.SpanID)
+		}
+	})
+
+	t.Run("OpenTelemetry span takes precedence", func(t *testing.T) {
+		ctx, span := trace.NewTracerProvider().Tracer("test").Start(ContextWithGeneratedIDs(context.Background()), "test-span")
+		defer span.End()
+
+		log := &Log{}
+		enrichLogWithContext(ctx, log)
+		generateMissingIDs(log)
+
+		if log.TraceID != span.SpanContext().TraceID().String() {
+			t.Errorf("TraceID = %q, want the span's %q", log.TraceID, span.SpanContext().TraceID())
+		}
+	})
+}
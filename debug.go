@@ -0,0 +1,78 @@
+package logtide
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// debugLogger writes human-readable internal events for interactive
+// debugging. A nil *debugLogger discards everything, so call sites need no
+// checks when debugging is off.
+type debugLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// newDebugLogger returns a logger writing to w, or nil if w is nil.
+func newDebugLogger(w io.Writer) *debugLogger {
+	if w == nil {
+		return nil
+	}
+	return &debugLogger{w: w}
+}
+
+// printf writes one event line. Write errors are ignored.
+func (d *debugLogger) printf(format string, args ...interface{}) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	fmt.Fprintf(d.w, "logtide: "+format+"\n", args...)
+}
+
+// flushed reports the outcome of sending a batch.
+func (d *debugLogger) flushed(count int, elapsed time.Duration, err error) {
+	if d == nil {
+		return
+	}
+	if err != nil {
+		d.printf("batch of %d logs failed after %v: %v", count, elapsed, err)
+		return
+	}
+	d.printf("batch of %d logs flushed in %v", count, elapsed)
+}
+
+// expired reports logs discarded at flush time for their age.
+func (d *debugLogger) expired(count int, maxAge time.Duration) {
+	d.printf("%d logs older than %v discarded", count, maxAge)
+}
+
+// retryScheduled reports that a failed attempt will be retried.
+func (d *debugLogger) retryScheduled(attempt int, backoff time.Duration, resp *http.Response, err error) {
+	if d == nil {
+		return
+	}
+	reason := "unknown error"
+	if err != nil {
+		reason = err.Error()
+	} else if resp != nil {
+		reason = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+	d.printf("attempt %d failed (%s), retry scheduled in %v", attempt+1, reason, backoff)
+}
+
+// circuitChanged reports a circuit breaker state transition.
+func (d *debugLogger) circuitChanged(from, to CircuitState) {
+	d.printf("circuit %s -> %s", from, to)
+}
+
+// unclosed reports that a client was garbage-collected without Close.
+func (d *debugLogger) unclosed(buffered int) {
+	d.printf("client garbage-collected without Close, stopping its batcher and discarding %d buffered logs", buffered)
+}
@@ -0,0 +1,109 @@
+package logtide
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebugLoggerNil(t *testing.T) {
+	var d *debugLogger
+	// Must not panic when debugging is off
+	d.printf("event %d", 1)
+	d.flushed(1, time.Millisecond, nil)
+	d.retryScheduled(0, time.Millisecond, nil, nil)
+	d.circuitChanged(CircuitClosed, CircuitOpen)
+
+	if newDebugLogger(nil) != nil {
+		t.Error("newDebugLogger(nil) should return nil")
+	}
+}
+
+func TestClientDebug(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first attempt to force a retry
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithRetry(2, 10*time.Millisecond, 10*time.Millisecond),
+		WithCircuitBreaker(1, time.Minute),
+		WithDebug(&out),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "test message", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"logtide: attempt 1 failed (HTTP 503), retry scheduled in",
+		"logtide: batch of 1 logs flushed in",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("debug output missing %q, got:\n%s", want, got)
+		}
+	}
+
+	// The retry must not have tainted the client's configuration
+	if client.config.RetryConfig.onRetry != nil {
+		t.Error("WithDebug modified the configured RetryConfig")
+	}
+}
+
+func TestClientDebugCircuitOpened(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithRetry(0, time.Millisecond, time.Millisecond),
+		WithCircuitBreaker(1, time.Minute),
+		WithDebug(&out),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "test message", nil)
+	client.Flush(ctx)
+
+	got := out.String()
+	for _, want := range []string{
+		"logtide: circuit closed -> open",
+		"logtide: batch of 1 logs failed after",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("debug output missing %q, got:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package logtide
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ANSI escape sequences used to color echoed levels.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiGray    = "\x1b[90m"
+	ansiCyan    = "\x1b[36m"
+	ansiYellow  = "\x1b[33m"
+	ansiRed     = "\x1b[31m"
+	ansiBoldRed = "\x1b[1;31m"
+)
+
+// echoColors maps each level to the color of its label.
+var echoColors = map[LogLevel]string{
+	LogLevelDebug:    ansiGray,
+	LogLevelInfo:     ansiCyan,
+	LogLevelWarn:     ansiYellow,
+	LogLevelError:    ansiRed,
+	LogLevelCritical: ansiBoldRed,
+}
+
+// echoWriter prints logs as human-readable lines, one per log, for local
+// development alongside shipping them.
+type echoWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	color bool
+}
+
+// newEchoWriter returns an echoWriter printing to w. Levels are colored if
+// colorized is set and the NO_COLOR environment variable is empty.
+func newEchoWriter(w io.Writer, colorized bool) *echoWriter {
+	return &echoWriter{w: w, color: colorized && os.Getenv("NO_COLOR") == ""}
+}
+
+// print writes log as a line of its time, level, service, message, and
+// metadata and tags as key=value pairs sorted by key. Write errors are ignored.
+func (e *echoWriter) print(log *Log) {
+	var b strings.Builder
+	b.WriteString(log.Time.Format("15:04:05.000"))
+	b.WriteByte(' ')
+
+	level := fmt.Sprintf("%-8s", strings.ToUpper(string(log.Level)))
+	if color, ok := echoColors[log.Level]; ok && e.color {
+		level = color + level + ansiReset
+	}
+	b.WriteString(level)
+
+	fmt.Fprintf(&b, "[%s] %s", log.Service, log.Message)
+	for _, key := range sortedKeys(log.Metadata) {
+		fmt.Fprintf(&b, " %s=%s", key, echoValue(log.Metadata[key]))
+	}
+	for _, key := range sortedKeys(log.Tags) {
+		fmt.Fprintf(&b, " %s=%s", key, echoValue(log.Tags[key]))
+	}
+	b.WriteByte('\n')
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	io.WriteString(e.w, b.String())
+}
+
+// echoValue renders a metadata value compactly: strings as is, quoted if they
+// contain spaces, and other values as compact JSON.
+func echoValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+			return fmt.Sprintf("%q", s)
+		}
+		return s
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
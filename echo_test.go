@@ -0,0 +1,99 @@
+package logtide
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureStdout redirects os.Stdout while fn runs and returns what was written.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() error = %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	fn()
+	w.Close()
+	return <-output
+}
+
+func TestClientStdoutEcho(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	server := newCaptureServer(t)
+
+	output := captureStdout(t, func() {
+		client, err := New(
+			WithAPIKey("lp_test_key"),
+			WithService("test-service"),
+			WithBaseURL(server.URL),
+			WithStdoutEcho(true),
+		)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer client.Close()
+
+		ctx := context.Background()
+		client.Error(ctx, "payment failed", map[string]interface{}{"order": 42, "reason": "card declined"})
+		client.Debug(ctx, "cache miss", nil)
+		if err := client.Flush(ctx); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+	})
+
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("echoed %q, want 2 lines", output)
+	}
+	for _, want := range []string{ansiRed + "ERROR", "[test-service] payment failed", `order=42 reason="card declined"`} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("echoed line %q does not contain %q", lines[0], want)
+		}
+	}
+	if want := ansiGray + "DEBUG"; !strings.Contains(lines[1], want) || !strings.Contains(lines[1], "cache miss") {
+		t.Errorf("echoed line %q, want a gray DEBUG line with the message", lines[1])
+	}
+
+	// Echoed logs are still shipped
+	if got := len(server.Logs()); got != 2 {
+		t.Errorf("server received %d logs, want 2", got)
+	}
+}
+
+func TestEchoWriterNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	var b strings.Builder
+	echo := newEchoWriter(&b, true)
+	echo.print(&Log{
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 600e6, time.UTC),
+		Service: "api",
+		Level:   LogLevelWarn,
+		Message: "slow request",
+		Metadata: map[string]interface{}{
+			"path":  "/users",
+			"query": map[string]interface{}{"page": 2},
+		},
+		Tags: map[string]string{"region": "eu"},
+	})
+
+	want := "03:04:05.600 WARN    [api] slow request path=/users query={\"page\":2} region=eu\n"
+	if got := b.String(); got != want {
+		t.Errorf("print() wrote %q, want %q", got, want)
+	}
+}
@@ -17,6 +17,18 @@ var (
 
 	// ErrClientClosed is returned when attempting to use a closed client.
 	ErrClientClosed = errors.New("client is closed")
+
+	// ErrDraining is returned when logging on a client that is draining.
+	ErrDraining = errors.New("client is draining")
+
+	// ErrLogTooLarge is returned when a single serialized log exceeds the configured size limit.
+	ErrLogTooLarge = errors.New("log exceeds maximum size")
+
+	// ErrQueueFull is returned when the log queue is full and the backpressure policy drops new logs.
+	ErrQueueFull = errors.New("log queue is full")
+
+	// ErrFlushPanicked is returned when the flush function panics.
+	ErrFlushPanicked = errors.New("flush function panicked")
 )
 
 // ValidationError represents a validation error for log data.
@@ -36,6 +48,43 @@ func (e *ValidationError) Is(target error) bool {
 	return ok
 }
 
+// ThrottledError is passed to the error handler in place of an error whose
+// kind was reported recently, when WithErrorHandlerThrottle is set. It wraps
+// the latest error and counts the errors of the same kind suppressed since
+// the previous report.
+type ThrottledError struct {
+	Err        error
+	Suppressed int
+}
+
+// Error implements the error interface.
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%v (%d similar errors suppressed)", e.Err, e.Suppressed)
+}
+
+// Unwrap returns the wrapped error.
+func (e *ThrottledError) Unwrap() error {
+	return e.Err
+}
+
+// DeliveryError reports logs that could not be delivered before a deadline,
+// such as the one given to CloseContext. Logs holds the undelivered logs so
+// they can be saved elsewhere.
+type DeliveryError struct {
+	Logs []Log
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("%d logs undelivered: %v", len(e.Logs), e.Err)
+}
+
+// Unwrap returns the error that stopped delivery, typically ctx.Err().
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
 // HTTPError represents an HTTP error response from the LogTide API.
 type HTTPError struct {
 	StatusCode int
@@ -57,6 +106,20 @@ func (e *HTTPError) Is(target error) bool {
 	return ok
 }
 
+// Unwrap maps well-known status codes to the SDK's sentinel errors, so
+// errors.Is(err, ErrTimeout) holds for a 504 and errors.Is(err, ErrInvalidAPIKey)
+// holds for a 401 or 403.
+func (e *HTTPError) Unwrap() error {
+	switch e.StatusCode {
+	case 401, 403: // Unauthorized, Forbidden
+		return ErrInvalidAPIKey
+	case 504: // Gateway Timeout
+		return ErrTimeout
+	default:
+		return nil
+	}
+}
+
 // IsRetryable returns true if the HTTP error indicates a retryable condition.
 func (e *HTTPError) IsRetryable() bool {
 	return e.StatusCode == 429 || // Too Many Requests
@@ -2,6 +2,7 @@ package logtide
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -149,3 +150,33 @@ func TestSentinelErrors(t *testing.T) {
 		t.Error("errors.Is() should match ErrCircuitOpen")
 	}
 }
+
+func TestHTTPErrorUnwrap(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		target     error
+		want       bool
+	}{
+		{name: "504 is a timeout", statusCode: 504, target: ErrTimeout, want: true},
+		{name: "401 is an invalid API key", statusCode: 401, target: ErrInvalidAPIKey, want: true},
+		{name: "403 is an invalid API key", statusCode: 403, target: ErrInvalidAPIKey, want: true},
+		{name: "500 is not a timeout", statusCode: 500, target: ErrTimeout, want: false},
+		{name: "504 is not an invalid API key", statusCode: 504, target: ErrInvalidAPIKey, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Wrap as sendBatch callers would see it
+			err := fmt.Errorf("failed to send batch: %w", &HTTPError{StatusCode: tt.statusCode})
+			if got := errors.Is(err, tt.target); got != tt.want {
+				t.Errorf("errors.Is(HTTP %d, %v) = %v, want %v", tt.statusCode, tt.target, got, tt.want)
+			}
+
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.statusCode {
+				t.Errorf("errors.As() did not recover HTTPError %d", tt.statusCode)
+			}
+		})
+	}
+}
@@ -66,11 +66,15 @@ func main() {
 			return
 		}
 
-		// Simulate login
-		client.Info(r.Context(), "User login attempt", map[string]interface{}{
+		// Simulate login, making sure the audit log is sent before responding
+		ctx := logtide.ContextWithFlushScope(r.Context())
+		client.Info(ctx, "User login attempt", map[string]interface{}{
 			"username": req.Username,
 			"success":  true,
 		})
+		if err := client.FlushScope(ctx); err != nil {
+			log.Printf("Failed to flush login audit log: %v", err)
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"encoding/json"
@@ -9,25 +10,48 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 )
 
 // Client wraps an HTTP client with LogTide-specific configuration.
 type Client struct {
-	httpClient *http.Client
-	baseURL    string
-	apiKey     string
-	timeout    time.Duration
+	httpClient   *http.Client
+	streamClient *http.Client // shares the transport but has no overall timeout
+	baseURL      string
+	userAgent    string
+	sdkVersion   string
+	timeout      time.Duration
+
+	requestInterceptor  func(*http.Request) error
+	responseInterceptor func(*http.Response)
+
+	mu     sync.RWMutex
+	apiKey string
 }
 
 // Config holds the configuration for the HTTP client.
 type Config struct {
-	BaseURL        string
-	APIKey         string
-	Timeout        time.Duration
-	MaxIdleConns   int
+	BaseURL         string
+	APIKey          string
+	Timeout         time.Duration
+	MaxIdleConns    int
 	IdleConnTimeout time.Duration
-	TLSMinVersion  uint16
+	TLSMinVersion   uint16
+
+	// UserAgent is the User-Agent header sent with every request.
+	UserAgent string
+
+	// SDKVersion is sent in the X-SDK-Version header when set.
+	SDKVersion string
+
+	// RequestInterceptor, if set, is called with each request after its
+	// headers are set and before it is sent. An error aborts the request.
+	RequestInterceptor func(*http.Request) error
+
+	// ResponseInterceptor, if set, is called with each response before its
+	// body is read.
+	ResponseInterceptor func(*http.Response)
 }
 
 // NewClient creates a new HTTP client with the specified configuration.
@@ -65,46 +89,118 @@ func NewClient(cfg *Config) *Client {
 			Transport: transport,
 			Timeout:   cfg.Timeout,
 		},
-		baseURL: cfg.BaseURL,
-		apiKey:  cfg.APIKey,
-		timeout: cfg.Timeout,
+		streamClient: &http.Client{
+			Transport: transport,
+		},
+		baseURL:             cfg.BaseURL,
+		apiKey:              cfg.APIKey,
+		userAgent:           cfg.UserAgent,
+		sdkVersion:          cfg.SDKVersion,
+		timeout:             cfg.Timeout,
+		requestInterceptor:  cfg.RequestInterceptor,
+		responseInterceptor: cfg.ResponseInterceptor,
+	}
+}
+
+// SetAPIKey replaces the API key sent with subsequent requests.
+func (c *Client) SetAPIKey(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apiKey = key
+}
+
+// Post sends a POST request to the specified path with a JSON-encoded body.
+// Headers in header are added to the LogTide headers; header may be nil.
+func (c *Client) Post(ctx context.Context, path string, body []byte, header http.Header) (*http.Response, error) {
+	req, err := c.newRequest(ctx, path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
 	}
+	return c.do(c.httpClient, req)
 }
 
-// Post sends a POST request to the specified path with the given payload.
-func (c *Client) Post(ctx context.Context, path string, payload interface{}) (*http.Response, error) {
-	// Marshal payload to JSON
-	body, err := json.Marshal(payload)
+// Stream sends a POST request to the specified path whose body is read from
+// body until it returns io.EOF, for long-lived NDJSON streams. Unlike Post it
+// is not bounded by the client timeout; cancel ctx to abandon the stream.
+func (c *Client) Stream(ctx context.Context, path string, body io.Reader) (*http.Response, error) {
+	req, err := c.newRequest(ctx, path, "application/x-ndjson", body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		return nil, err
 	}
+	return c.do(c.streamClient, req)
+}
 
+// newRequest creates a POST request with the LogTide headers set.
+func (c *Client) newRequest(ctx context.Context, path, contentType string, body io.Reader) (*http.Request, error) {
 	// Create request
 	url := c.baseURL + path
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-API-Key", c.apiKey)
-	req.Header.Set("User-Agent", "logtide-sdk-go/0.1.0")
+	req.Header.Set("Content-Type", contentType)
+	c.mu.RLock()
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	c.mu.RUnlock()
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept-Encoding", "gzip")
+	if c.sdkVersion != "" {
+		req.Header.Set("X-SDK-Version", c.sdkVersion)
+	}
+
+	return req, nil
+}
+
+// do runs the interceptors around sending req with httpClient.
+func (c *Client) do(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	// Let the caller sign or adjust the final request
+	if c.requestInterceptor != nil {
+		if err := c.requestInterceptor(req); err != nil {
+			return nil, fmt.Errorf("request interceptor: %w", err)
+		}
+	}
 
 	// Send request
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
+	if c.responseInterceptor != nil {
+		c.responseInterceptor(resp)
+	}
+
 	return resp, nil
 }
 
+// readBody reads the entire response body, decompressing it if the server
+// gzip-encoded it. Setting Accept-Encoding explicitly turns off the
+// transport's own decompression, so it is done here.
+func readBody(resp *http.Response) ([]byte, error) {
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		return io.ReadAll(resp.Body)
+	}
+
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
 // DecodeResponse decodes the JSON response body into the provided target.
 func DecodeResponse(resp *http.Response, target interface{}) error {
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readBody(resp)
 	if err != nil {
 		return fmt.Errorf("failed to read response body: %w", err)
 	}
@@ -120,7 +216,7 @@ func DecodeResponse(resp *http.Response, target interface{}) error {
 func ReadResponseBody(resp *http.Response) (string, error) {
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readBody(resp)
 	if err != nil {
 		return "", fmt.Errorf("failed to read response body: %w", err)
 	}
@@ -0,0 +1,18 @@
+module github.com/logtide-dev/logtide-sdk-go/kafka
+
+go 1.25.4
+
+require (
+	github.com/logtide-dev/logtide-sdk-go v0.1.0
+	github.com/segmentio/kafka-go v0.4.49
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	go.opentelemetry.io/otel v1.38.0 // indirect
+	go.opentelemetry.io/otel/trace v1.38.0 // indirect
+)
+
+replace github.com/logtide-dev/logtide-sdk-go => ../
@@ -0,0 +1,105 @@
+// Package kafka provides a LogTide SDK sink that produces batches of logs to
+// a Kafka topic instead of sending them to the ingest API, for deployments
+// that forward logs to LogTide through Kafka. It is a separate module so the
+// SDK itself does not depend on github.com/segmentio/kafka-go.
+//
+//	writer := &kafkago.Writer{
+//		Addr:     kafkago.TCP("localhost:9092"),
+//		Balancer: &kafkago.Hash{},
+//	}
+//	defer writer.Close()
+//
+//	client, err := logtide.New(
+//		logtide.WithService("my-service"),
+//		logtide.WithSink(kafka.NewSink(writer, "logs")),
+//	)
+//
+// The client batches, validates, and transforms logs as usual before they
+// reach the sink, and produce errors go to the client's error handler and
+// dead-letter callback like failed ingest requests.
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	logtide "github.com/logtide-dev/logtide-sdk-go"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// Producer writes messages to Kafka. *kafkago.Writer implements it; it must
+// not have a Topic of its own, since the sink sets the topic of each message.
+type Producer interface {
+	WriteMessages(ctx context.Context, msgs ...kafkago.Message) error
+}
+
+// Sink is a logtide.Sink that produces each batch as one Kafka message. The
+// message value is the JSON ingest request the batch would be sent as,
+// {"logs":[...]}, so a consumer can post it to the ingest API unchanged.
+type Sink struct {
+	producer Producer
+	topic    string
+	key      func(logs []logtide.Log) []byte
+}
+
+// Option configures a Sink.
+type Option func(*Sink)
+
+// WithKey sets the function computing the message key of each batch, which
+// decides its partition with a hashing balancer. By default the key is the
+// service of the batch if all its logs share one, as they do with
+// logtide.WithPerServiceBatching(true), and empty otherwise.
+func WithKey(key func(logs []logtide.Log) []byte) Option {
+	return func(s *Sink) {
+		s.key = key
+	}
+}
+
+// NewSink creates a Sink producing batches to topic through producer.
+func NewSink(producer Producer, topic string, opts ...Option) *Sink {
+	s := &Sink{
+		producer: producer,
+		topic:    topic,
+		key:      serviceKey,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Send implements logtide.Sink.
+func (s *Sink) Send(ctx context.Context, logs []logtide.Log) error {
+	value, err := json.Marshal(&logtide.IngestRequest{Logs: logs})
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch: %w", err)
+	}
+
+	msg := kafkago.Message{
+		Topic: s.topic,
+		Key:   s.key(logs),
+		Value: value,
+		Headers: []kafkago.Header{
+			{Key: "Content-Type", Value: []byte("application/json")},
+		},
+	}
+	if err := s.producer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to produce batch to topic %q: %w", s.topic, err)
+	}
+	return nil
+}
+
+// serviceKey returns the service shared by every log, or nil if they differ.
+func serviceKey(logs []logtide.Log) []byte {
+	if len(logs) == 0 {
+		return nil
+	}
+	service := logs[0].Service
+	for _, log := range logs[1:] {
+		if log.Service != service {
+			return nil
+		}
+	}
+	return []byte(service)
+}
@@ -0,0 +1,134 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	logtide "github.com/logtide-dev/logtide-sdk-go"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// mockProducer records the messages it is given and fails with err if set.
+type mockProducer struct {
+	mu   sync.Mutex
+	msgs []kafkago.Message
+	err  error
+}
+
+func (p *mockProducer) WriteMessages(ctx context.Context, msgs ...kafkago.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.msgs = append(p.msgs, msgs...)
+	return nil
+}
+
+func (p *mockProducer) Messages() []kafkago.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]kafkago.Message(nil), p.msgs...)
+}
+
+func TestSink(t *testing.T) {
+	producer := &mockProducer{}
+	client, err := logtide.New(
+		logtide.WithService("api"),
+		logtide.WithSink(NewSink(producer, "logs")),
+		logtide.WithPerServiceBatching(true),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "request handled", map[string]interface{}{"status": 200})
+	client.Info(logtide.ContextWithService(ctx, "worker"), "job done", nil)
+	client.Warn(ctx, "slow request", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	msgs := producer.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("produced %d messages, want one per service", len(msgs))
+	}
+
+	want := map[string][]string{
+		"api":    {"request handled", "slow request"},
+		"worker": {"job done"},
+	}
+	for _, msg := range msgs {
+		if msg.Topic != "logs" {
+			t.Errorf("message topic = %q, want %q", msg.Topic, "logs")
+		}
+
+		var req logtide.IngestRequest
+		if err := json.Unmarshal(msg.Value, &req); err != nil {
+			t.Fatalf("message value %s is not an ingest request: %v", msg.Value, err)
+		}
+		service := string(msg.Key)
+		if len(req.Logs) != len(want[service]) {
+			t.Fatalf("message with key %q holds %d logs, want %d", service, len(req.Logs), len(want[service]))
+		}
+		for i, log := range req.Logs {
+			if log.Service != service || log.Message != want[service][i] {
+				t.Errorf("message with key %q log %d = %s %q, want %s %q", service, i, log.Service, log.Message, service, want[service][i])
+			}
+		}
+	}
+}
+
+func TestSinkKey(t *testing.T) {
+	producer := &mockProducer{}
+	logs := []logtide.Log{{Service: "api"}, {Service: "worker"}}
+
+	if err := NewSink(producer, "logs").Send(context.Background(), logs); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	err := NewSink(producer, "logs", WithKey(func(logs []logtide.Log) []byte {
+		return []byte("fixed")
+	})).Send(context.Background(), logs)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	msgs := producer.Messages()
+	if msgs[0].Key != nil {
+		t.Errorf("key of a mixed-service batch = %q, want none", msgs[0].Key)
+	}
+	if string(msgs[1].Key) != "fixed" {
+		t.Errorf("key with WithKey = %q, want %q", msgs[1].Key, "fixed")
+	}
+}
+
+func TestSinkProduceError(t *testing.T) {
+	errBroker := errors.New("broker unavailable")
+	var deadLettered []logtide.Log
+	var deadErr error
+	client, err := logtide.New(
+		logtide.WithService("api"),
+		logtide.WithSink(NewSink(&mockProducer{err: errBroker}, "logs")),
+		logtide.WithDeadLetter(func(logs []logtide.Log, err error) {
+			deadLettered, deadErr = logs, err
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "request handled", nil)
+	if err := client.Flush(ctx); !errors.Is(err, errBroker) {
+		t.Errorf("Flush() error = %v, want the produce error", err)
+	}
+	if len(deadLettered) != 1 || !errors.Is(deadErr, errBroker) {
+		t.Errorf("dead letter got %d logs with error %v, want the batch with the produce error", len(deadLettered), deadErr)
+	}
+}
@@ -0,0 +1,16 @@
+module github.com/logtide-dev/logtide-sdk-go/logrsink
+
+go 1.25.4
+
+require (
+	github.com/go-logr/logr v1.4.3
+	github.com/logtide-dev/logtide-sdk-go v0.1.0
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/otel v1.38.0 // indirect
+	go.opentelemetry.io/otel/trace v1.38.0 // indirect
+)
+
+replace github.com/logtide-dev/logtide-sdk-go => ../
@@ -0,0 +1,160 @@
+// Package logrsink provides a go-logr LogSink that sends logs to LogTide, so
+// libraries and controllers written against logr can log through a client. It
+// is a separate module so the SDK itself does not depend on
+// github.com/go-logr/logr.
+//
+//	client, err := logtide.New(logtide.WithAPIKey(key), logtide.WithService("operator"))
+//	...
+//	log := logrsink.New(client, 1)
+//	log.WithName("reconciler").Info("reconciled", "namespace", ns)
+//
+// logr has no warn level: Info at V-level 0 becomes an info log, higher
+// V-levels become debug logs, and Error becomes an error log.
+//
+// With logtide.WithCaller, logs record the code calling the logr.Logger, not
+// the logr package itself.
+package logrsink
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	logtide "github.com/logtide-dev/logtide-sdk-go"
+)
+
+const (
+	// nameKey is the metadata key holding the logger name built by WithName.
+	nameKey = "logger"
+
+	// errorKey is the metadata key holding the error passed to Error.
+	errorKey = "error"
+
+	// missingValue is used for a key passed without a value.
+	missingValue = "<no-value>"
+)
+
+// Sink is a logr.LogSink backed by a LogTide client. Logs are sent with a
+// background context, and errors from the client are dropped since logr has
+// no way to report them; use logtide.WithErrorHandler to observe delivery
+// failures.
+type Sink struct {
+	client    *logtide.Client
+	verbosity int
+	name      string
+	values    []interface{}
+	callDepth int
+}
+
+var (
+	_ logr.LogSink          = (*Sink)(nil)
+	_ logr.CallDepthLogSink = (*Sink)(nil)
+)
+
+// New returns a logr.Logger sending logs through client. Info logs above the
+// given V-level are discarded.
+func New(client *logtide.Client, verbosity int) logr.Logger {
+	return logr.New(NewSink(client, verbosity))
+}
+
+// NewSink creates a sink sending logs through client. Info logs above the
+// given V-level are discarded.
+func NewSink(client *logtide.Client, verbosity int) *Sink {
+	return &Sink{client: client, verbosity: verbosity}
+}
+
+// Init implements logr.LogSink. The call depth is the number of logr frames
+// skipped, on top of the sink's own, to find the caller recorded by
+// logtide.WithCaller.
+func (s *Sink) Init(info logr.RuntimeInfo) {
+	s.callDepth = info.CallDepth
+}
+
+// WithCallDepth implements logr.CallDepthLogSink, returning a sink skipping
+// depth more frames to find the caller, for helpers wrapping the logger.
+func (s *Sink) WithCallDepth(depth int) logr.LogSink {
+	clone := *s
+	clone.callDepth += depth
+	return &clone
+}
+
+// Enabled reports whether Info logs at the given V-level are sent.
+func (s *Sink) Enabled(level int) bool {
+	return level <= s.verbosity
+}
+
+// Info sends an info log at V-level 0 and a debug log at higher V-levels.
+func (s *Sink) Info(level int, msg string, keysAndValues ...interface{}) {
+	logLevel := logtide.LogLevelInfo
+	if level > 0 {
+		logLevel = logtide.LogLevelDebug
+	}
+	s.client.LogContext(s.context(), logLevel, msg, s.metadata(keysAndValues))
+}
+
+// Error sends an error log with err under the "error" key.
+func (s *Sink) Error(err error, msg string, keysAndValues ...interface{}) {
+	metadata := s.metadata(keysAndValues)
+	if err != nil {
+		metadata[errorKey] = err.Error()
+	}
+	s.client.LogContext(s.context(), logtide.LogLevelError, msg, metadata)
+}
+
+// WithValues returns a sink that adds keysAndValues to every log. Values
+// passed at the call site win over accumulated ones with the same key.
+func (s *Sink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	clone := *s
+	clone.values = append(append([]interface{}(nil), s.values...), keysAndValues...)
+	return &clone
+}
+
+// WithName returns a sink whose logs carry name under the "logger" key,
+// appended to the existing name with a dot.
+func (s *Sink) WithName(name string) logr.LogSink {
+	clone := *s
+	if s.name != "" {
+		name = s.name + "." + name
+	}
+	clone.name = name
+	return &clone
+}
+
+// context returns the background context logs are sent with, skipping the
+// logr frames between the sink and its caller.
+func (s *Sink) context() context.Context {
+	return logtide.ContextWithCallerSkip(context.Background(), s.callDepth)
+}
+
+// metadata builds the metadata for one log from the logger name, accumulated
+// values, and call-site key/value pairs, in increasing precedence.
+func (s *Sink) metadata(keysAndValues []interface{}) map[string]interface{} {
+	metadata := make(map[string]interface{}, (len(s.values)+len(keysAndValues))/2+1)
+	if s.name != "" {
+		metadata[nameKey] = s.name
+	}
+	addPairs(metadata, s.values)
+	addPairs(metadata, keysAndValues)
+	return metadata
+}
+
+// addPairs adds alternating keys and values to metadata. Non-string keys are
+// formatted with fmt.Sprint, and values implementing logr.Marshaler are
+// replaced by their MarshalLog result.
+func addPairs(metadata map[string]interface{}, keysAndValues []interface{}) {
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+
+		var value interface{} = missingValue
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		if m, ok := value.(logr.Marshaler); ok {
+			value = m.MarshalLog()
+		}
+		metadata[key] = value
+	}
+}
@@ -0,0 +1,159 @@
+package logrsink
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	logtide "github.com/logtide-dev/logtide-sdk-go"
+)
+
+// recordingSink is a logtide.Sink that records every log it receives.
+type recordingSink struct {
+	mu   sync.Mutex
+	logs []logtide.Log
+}
+
+func (s *recordingSink) Send(ctx context.Context, logs []logtide.Log) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logs = append(s.logs, logs...)
+	return nil
+}
+
+// newLogger returns a logger at verbosity 1 and a function flushing the
+// client and returning the logs received so far.
+func newLogger(t *testing.T) (logr.Logger, func() []logtide.Log) {
+	t.Helper()
+
+	sink := &recordingSink{}
+	client, err := logtide.New(
+		logtide.WithService("test-service"),
+		logtide.WithSink(sink),
+		logtide.WithFlushInterval(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("logtide.New() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return New(client, 1), func() []logtide.Log {
+		if err := client.Flush(context.Background()); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		return append([]logtide.Log(nil), sink.logs...)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	log, flush := newLogger(t)
+
+	log.Info("reconciled", "namespace", "default", "attempts", 2)
+	log.V(1).Info("cache hit")
+	log.V(2).Info("too verbose")
+
+	logs := flush()
+	if len(logs) != 2 {
+		t.Fatalf("received %d logs, want 2", len(logs))
+	}
+	if logs[0].Level != logtide.LogLevelInfo || logs[0].Message != "reconciled" {
+		t.Errorf("first log = %+v, want info %q", logs[0], "reconciled")
+	}
+	if logs[0].Metadata["namespace"] != "default" || logs[0].Metadata["attempts"] != 2 {
+		t.Errorf("Metadata = %v, want namespace and attempts", logs[0].Metadata)
+	}
+	if logs[1].Level != logtide.LogLevelDebug {
+		t.Errorf("V(1) level = %q, want %q", logs[1].Level, logtide.LogLevelDebug)
+	}
+}
+
+func TestError(t *testing.T) {
+	log, flush := newLogger(t)
+
+	log.Error(errors.New("connection refused"), "sync failed", "resource", "pods")
+
+	logs := flush()
+	if len(logs) != 1 {
+		t.Fatalf("received %d logs, want 1", len(logs))
+	}
+	got := logs[0]
+	if got.Level != logtide.LogLevelError || got.Message != "sync failed" {
+		t.Errorf("log = %+v, want error %q", got, "sync failed")
+	}
+	if got.Metadata["error"] != "connection refused" || got.Metadata["resource"] != "pods" {
+		t.Errorf("Metadata = %v, want error and resource", got.Metadata)
+	}
+}
+
+func TestWithValuesAndName(t *testing.T) {
+	log, flush := newLogger(t)
+
+	base := log.WithName("controller").WithValues("cluster", "east", "kind", "Pod")
+	derived := base.WithName("reconciler").WithValues("request", "default/web")
+
+	derived.Info("started", "kind", "Deployment")
+	base.Info("base only")
+	log.Info("odd pair", "dangling")
+
+	logs := flush()
+	if len(logs) != 3 {
+		t.Fatalf("received %d logs, want 3", len(logs))
+	}
+
+	want := map[string]interface{}{
+		"logger":  "controller.reconciler",
+		"cluster": "east",
+		"kind":    "Deployment",
+		"request": "default/web",
+	}
+	for k, v := range want {
+		if logs[0].Metadata[k] != v {
+			t.Errorf("Metadata[%q] = %v, want %v", k, logs[0].Metadata[k], v)
+		}
+	}
+
+	// Values added to a derived logger do not leak into its parent
+	if _, ok := logs[1].Metadata["request"]; ok || logs[1].Metadata["logger"] != "controller" {
+		t.Errorf("base Metadata = %v, want only the base name and values", logs[1].Metadata)
+	}
+	if logs[2].Metadata["dangling"] != "<no-value>" {
+		t.Errorf("Metadata[dangling] = %v, want %q", logs[2].Metadata["dangling"], "<no-value>")
+	}
+}
+
+func TestCaller(t *testing.T) {
+	sink := &recordingSink{}
+	client, err := logtide.New(
+		logtide.WithService("test-service"),
+		logtide.WithSink(sink),
+		logtide.WithCaller(true),
+	)
+	if err != nil {
+		t.Fatalf("logtide.New() error = %v", err)
+	}
+	defer client.Close()
+
+	log := New(client, 0)
+	_, file, line, _ := runtime.Caller(0)
+	log.Info("reconciled")
+	log.Error(errors.New("conflict"), "update failed")
+	if err := client.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if len(sink.logs) != 2 {
+		t.Fatalf("received %d logs, want 2", len(sink.logs))
+	}
+	for i, entry := range sink.logs {
+		caller, _ := entry.Metadata["caller"].(map[string]interface{})
+		if caller["file"] != file || caller["line"] != line+1+i {
+			t.Errorf("%q caller = %v:%v (%v), want %v:%v", entry.Message, caller["file"], caller["line"], caller["function"], file, line+1+i)
+		}
+	}
+}
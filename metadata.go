@@ -0,0 +1,292 @@
+package logtide
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+)
+
+// MergeMode controls how call-site metadata is combined with default metadata
+// when both contain the same key.
+type MergeMode int
+
+const (
+	// MergeOverride replaces the default value with the call-site value.
+	MergeOverride MergeMode = iota
+
+	// MergeAppendSlices appends the call-site value to the default value when
+	// both are slices. Other conflicts are resolved as with MergeOverride.
+	MergeAppendSlices
+)
+
+// mergeMetadata returns a new map containing base overlaid with override.
+// Keys in override win, except that slices are appended under
+// MergeAppendSlices. It returns override unchanged if base is empty, and
+// never mutates either input.
+func mergeMetadata(base, override map[string]interface{}, mode MergeMode) map[string]interface{} {
+	if len(base) == 0 {
+		return override
+	}
+
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		if existing, ok := merged[k]; ok && mode == MergeAppendSlices {
+			if appended, ok := appendSlices(existing, v); ok {
+				merged[k] = appended
+				continue
+			}
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// appendSlices appends b to a if both are slices. Slices of the same type keep
+// their type; otherwise the result is a []interface{}.
+func appendSlices(a, b interface{}) (interface{}, bool) {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	if av.Kind() != reflect.Slice || bv.Kind() != reflect.Slice {
+		return nil, false
+	}
+
+	if av.Type() == bv.Type() {
+		// Copy first so the append can never write into the caller's backing array
+		out := reflect.MakeSlice(av.Type(), 0, av.Len()+bv.Len())
+		out = reflect.AppendSlice(out, av)
+		return reflect.AppendSlice(out, bv).Interface(), true
+	}
+
+	out := make([]interface{}, 0, av.Len()+bv.Len())
+	for i := 0; i < av.Len(); i++ {
+		out = append(out, av.Index(i).Interface())
+	}
+	for i := 0; i < bv.Len(); i++ {
+		out = append(out, bv.Index(i).Interface())
+	}
+	return out, true
+}
+
+// errorMetadata returns the metadata CaptureError attaches for err.
+func errorMetadata(err error) map[string]interface{} {
+	// Name the cause rather than a wrapper such as *fmt.wrapError
+	cause := err
+	for inner := errors.Unwrap(cause); inner != nil; inner = errors.Unwrap(cause) {
+		cause = inner
+	}
+
+	metadata := map[string]interface{}{
+		"error_type": fmt.Sprintf("%T", cause),
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		metadata["status_code"] = httpErr.StatusCode
+	}
+	return metadata
+}
+
+// mergeTags returns a new map containing base overlaid with override. It
+// returns override unchanged if base is empty, and never mutates either input.
+func mergeTags(base, override map[string]string) map[string]string {
+	if len(base) == 0 {
+		return override
+	}
+
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// truncationMarker is appended to string values shortened by truncateMetadata.
+const truncationMarker = "...[truncated]"
+
+// truncateMetadata returns a copy of metadata in which string values longer
+// than maxBytes are cut and marked, and other values whose JSON encoding
+// exceeds maxBytes are replaced with a placeholder. Nested maps and slices are
+// walked recursively. It never mutates its input.
+func truncateMetadata(metadata map[string]interface{}, maxBytes int) map[string]interface{} {
+	truncated := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		truncated[k] = truncateValue(v, maxBytes)
+	}
+	return truncated
+}
+
+// truncateValue applies the truncateMetadata rules to a single value.
+func truncateValue(v interface{}, maxBytes int) interface{} {
+	switch val := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case string:
+		return truncateString(val, maxBytes)
+	case map[string]interface{}:
+		return truncateMetadata(val, maxBytes)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, elem := range val {
+			out[i] = truncateValue(elem, maxBytes)
+		}
+		return out
+	}
+
+	// Values that fail to encode are left for log validation to report
+	data, err := json.Marshal(v)
+	if err != nil || len(data) <= maxBytes {
+		return v
+	}
+	return fmt.Sprintf("[value omitted: %d bytes]", len(data))
+}
+
+// truncateString cuts s to at most maxBytes on a rune boundary and appends the
+// truncation marker.
+func truncateString(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + truncationMarker
+}
+
+// sanitizeMetadata replaces values that cannot be encoded as JSON, such as
+// channels, functions, and NaN, with a string naming their type, so one bad
+// value cannot fail the encoding of a whole batch. Nested maps and slices are
+// walked recursively. It returns metadata itself, and false, when every value
+// can be encoded, and otherwise a sanitized copy.
+func sanitizeMetadata(metadata map[string]interface{}) (map[string]interface{}, bool) {
+	var sanitized map[string]interface{}
+	for k, v := range metadata {
+		clean, changed := sanitizeValue(v)
+		if !changed {
+			continue
+		}
+		if sanitized == nil {
+			sanitized = make(map[string]interface{}, len(metadata))
+			for k, v := range metadata {
+				sanitized[k] = v
+			}
+		}
+		sanitized[k] = clean
+	}
+	if sanitized == nil {
+		return metadata, false
+	}
+	return sanitized, true
+}
+
+// sanitizeValue applies the sanitizeMetadata rules to a single value and
+// reports whether it was changed.
+func sanitizeValue(v interface{}) (interface{}, bool) {
+	switch val := v.(type) {
+	case nil, bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return v, false
+	case float64:
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return unserializable(v), true
+		}
+		return v, false
+	case float32:
+		if math.IsNaN(float64(val)) || math.IsInf(float64(val), 0) {
+			return unserializable(v), true
+		}
+		return v, false
+	case map[string]interface{}:
+		return sanitizeMetadata(val)
+	case []interface{}:
+		var out []interface{}
+		for i, elem := range val {
+			clean, changed := sanitizeValue(elem)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = append([]interface{}(nil), val...)
+			}
+			out[i] = clean
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	}
+
+	// Other types are only checked by encoding them
+	if _, err := json.Marshal(v); err != nil {
+		return unserializable(v), true
+	}
+	return v, false
+}
+
+// unserializable returns the placeholder for a value that cannot be encoded.
+func unserializable(v interface{}) string {
+	return fmt.Sprintf("[unserializable: %T]", v)
+}
+
+// flattenMetadata returns a copy of metadata in which nested maps and slices
+// are replaced by their leaves under joined keys, e.g. {"user": {"id": 1}}
+// becomes {"user.id": 1} with separator ".", and slice elements are keyed by
+// index. Empty maps and slices are kept as they are. Keys that were already
+// flat win over flattened keys that collide with them. It never mutates its
+// input.
+func flattenMetadata(metadata map[string]interface{}, separator string) map[string]interface{} {
+	flat := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		if !isNested(v) {
+			flat[k] = v
+		}
+	}
+	for k, v := range metadata {
+		if isNested(v) {
+			flattenValue(flat, k, v, separator)
+		}
+	}
+	return flat
+}
+
+// flattenValue adds v to flat under key, recursing into nested maps and slices.
+func flattenValue(flat map[string]interface{}, key string, v interface{}, separator string) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) > 0 {
+			for k, elem := range val {
+				flattenValue(flat, key+separator+k, elem, separator)
+			}
+			return
+		}
+	case []interface{}:
+		if len(val) > 0 {
+			for i, elem := range val {
+				flattenValue(flat, key+separator+strconv.Itoa(i), elem, separator)
+			}
+			return
+		}
+	}
+	if _, exists := flat[key]; !exists {
+		flat[key] = v
+	}
+}
+
+// isNested reports whether v is a non-empty map or slice that flattenMetadata
+// expands.
+func isNested(v interface{}) bool {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return len(val) > 0
+	case []interface{}:
+		return len(val) > 0
+	}
+	return false
+}
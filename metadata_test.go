@@ -0,0 +1,198 @@
+package logtide
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     map[string]interface{}
+		override map[string]interface{}
+		mode     MergeMode
+		want     map[string]interface{}
+	}{
+		{
+			name:     "override replaces slices",
+			base:     map[string]interface{}{"tags": []string{"api"}},
+			override: map[string]interface{}{"tags": []string{"checkout"}},
+			mode:     MergeOverride,
+			want:     map[string]interface{}{"tags": []string{"checkout"}},
+		},
+		{
+			name:     "append slices of the same type",
+			base:     map[string]interface{}{"tags": []string{"api"}},
+			override: map[string]interface{}{"tags": []string{"checkout", "v2"}},
+			mode:     MergeAppendSlices,
+			want:     map[string]interface{}{"tags": []string{"api", "checkout", "v2"}},
+		},
+		{
+			name:     "append slices of different types",
+			base:     map[string]interface{}{"tags": []string{"api"}},
+			override: map[string]interface{}{"tags": []interface{}{"checkout", 2}},
+			mode:     MergeAppendSlices,
+			want:     map[string]interface{}{"tags": []interface{}{"api", "checkout", 2}},
+		},
+		{
+			name:     "scalar conflicts still override",
+			base:     map[string]interface{}{"region": "eu", "tags": []string{"api"}},
+			override: map[string]interface{}{"region": "us"},
+			mode:     MergeAppendSlices,
+			want:     map[string]interface{}{"region": "us", "tags": []string{"api"}},
+		},
+		{
+			name:     "slice and scalar conflict overrides",
+			base:     map[string]interface{}{"tags": []string{"api"}},
+			override: map[string]interface{}{"tags": "single"},
+			mode:     MergeAppendSlices,
+			want:     map[string]interface{}{"tags": "single"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeMetadata(tt.base, tt.override, tt.mode)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeMetadata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeMetadataDoesNotMutateInputs(t *testing.T) {
+	defaults := make([]string, 1, 10) // spare capacity an append could write into
+	defaults[0] = "api"
+	base := map[string]interface{}{"tags": defaults}
+
+	mergeMetadata(base, map[string]interface{}{"tags": []string{"first"}}, MergeAppendSlices)
+	second := mergeMetadata(base, map[string]interface{}{"tags": []string{"second"}}, MergeAppendSlices)
+
+	if want := []string{"api", "second"}; !reflect.DeepEqual(second["tags"], want) {
+		t.Errorf("tags = %v, want %v", second["tags"], want)
+	}
+	if len(base["tags"].([]string)) != 1 {
+		t.Errorf("base tags modified: %v", base["tags"])
+	}
+}
+
+func TestTruncateMetadata(t *testing.T) {
+	giant := strings.Repeat("x", 10000)
+	metadata := map[string]interface{}{
+		"body":   giant,
+		"status": 200,
+		"short":  "ok",
+		"nested": map[string]interface{}{
+			"body": giant,
+			"list": []interface{}{giant, "ok"},
+		},
+		"blob": []string{giant},
+	}
+
+	got := truncateMetadata(metadata, 16)
+
+	want := map[string]interface{}{
+		"body":   strings.Repeat("x", 16) + truncationMarker,
+		"status": 200,
+		"short":  "ok",
+		"nested": map[string]interface{}{
+			"body": strings.Repeat("x", 16) + truncationMarker,
+			"list": []interface{}{strings.Repeat("x", 16) + truncationMarker, "ok"},
+		},
+		"blob": "[value omitted: 10004 bytes]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("truncateMetadata() = %v, want %v", got, want)
+	}
+
+	if metadata["body"] != giant {
+		t.Error("truncateMetadata() mutated its input")
+	}
+}
+
+func TestTruncateStringRuneBoundary(t *testing.T) {
+	// "é" is two bytes; cutting at 3 would split the second one
+	got := truncateString("éééé", 3)
+	if want := "é" + truncationMarker; got != want {
+		t.Errorf("truncateString() = %q, want %q", got, want)
+	}
+}
+
+func TestSanitizeMetadata(t *testing.T) {
+	ch := make(chan int)
+	metadata := map[string]interface{}{
+		"ok":       "value",
+		"callback": func() {},
+		"events":   ch,
+		"ratio":    math.NaN(),
+		"nested": map[string]interface{}{
+			"list": []interface{}{1, ch},
+		},
+	}
+
+	got, changed := sanitizeMetadata(metadata)
+	if !changed {
+		t.Fatal("sanitizeMetadata() changed = false, want true")
+	}
+
+	want := map[string]interface{}{
+		"ok":       "value",
+		"callback": "[unserializable: func()]",
+		"events":   "[unserializable: chan int]",
+		"ratio":    "[unserializable: float64]",
+		"nested": map[string]interface{}{
+			"list": []interface{}{1, "[unserializable: chan int]"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sanitizeMetadata() = %v, want %v", got, want)
+	}
+	if _, err := json.Marshal(got); err != nil {
+		t.Errorf("sanitized metadata still fails to encode: %v", err)
+	}
+	if metadata["events"] != ch {
+		t.Error("sanitizeMetadata() mutated its input")
+	}
+
+	clean := map[string]interface{}{"status": 200, "tags": []interface{}{"a"}}
+	if _, changed := sanitizeMetadata(clean); changed {
+		t.Error("sanitizeMetadata() changed encodable metadata")
+	}
+}
+
+func TestFlattenMetadata(t *testing.T) {
+	metadata := map[string]interface{}{
+		"user": map[string]interface{}{
+			"id":      42,
+			"address": map[string]interface{}{"city": "Berlin"},
+		},
+		"tags":   []interface{}{"api", "v2"},
+		"status": 200,
+		"empty":  map[string]interface{}{},
+	}
+
+	got := flattenMetadata(metadata, ".")
+
+	want := map[string]interface{}{
+		"user.id":           42,
+		"user.address.city": "Berlin",
+		"tags.0":            "api",
+		"tags.1":            "v2",
+		"status":            200,
+		"empty":             map[string]interface{}{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("flattenMetadata() = %v, want %v", got, want)
+	}
+
+	if _, ok := metadata["user"].(map[string]interface{}); !ok || len(metadata) != 4 {
+		t.Error("flattenMetadata() mutated its input")
+	}
+
+	if got := flattenMetadata(map[string]interface{}{"a": map[string]interface{}{"b": 1}}, "_"); !reflect.DeepEqual(got, map[string]interface{}{"a_b": 1}) {
+		t.Errorf("flattenMetadata() with separator %q = %v", "_", got)
+	}
+}
@@ -0,0 +1,150 @@
+// Package otellog exports OpenTelemetry log records to LogTide.
+//
+// Exporter implements the OpenTelemetry logs SDK exporter interface, so
+// applications already emitting logs through the OpenTelemetry logs API can
+// ship them to LogTide without a separate logging call. It is a separate
+// module so the SDK itself does not depend on the OpenTelemetry logs API and
+// SDK.
+//
+//	client, err := logtide.New(logtide.WithAPIKey(key), logtide.WithService("api"))
+//	...
+//	provider := sdklog.NewLoggerProvider(
+//		sdklog.WithProcessor(sdklog.NewBatchProcessor(otellog.NewExporter(client))),
+//	)
+//
+// Records are handed to the client like any other log, so they go through the
+// same enrichment, batching, retries, and circuit breaker. They never record
+// a caller under logtide.WithCaller: exports run in the OpenTelemetry log
+// processor, away from the code that emitted the record.
+package otellog
+
+import (
+	"context"
+	"errors"
+
+	logtide "github.com/logtide-dev/logtide-sdk-go"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// Exporter is an OpenTelemetry logs SDK exporter that sends records through a
+// LogTide client. The client remains owned by the caller: Shutdown flushes it
+// but does not close it.
+type Exporter struct {
+	client *logtide.Client
+}
+
+var _ sdklog.Exporter = (*Exporter)(nil)
+
+// NewExporter creates an exporter sending records through client.
+func NewExporter(client *logtide.Client) *Exporter {
+	return &Exporter{client: client}
+}
+
+// Export converts records to LogTide logs and adds them to the client's batch.
+// Records that cannot be enqueued do not stop the rest; their errors are
+// joined and returned.
+func (e *Exporter) Export(ctx context.Context, records []sdklog.Record) error {
+	ctx = logtide.ContextWithoutCaller(ctx)
+	var errs []error
+	for i := range records {
+		if err := e.client.Emit(ctx, convertRecord(&records[i])); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ForceFlush sends all logs buffered by the client.
+func (e *Exporter) ForceFlush(ctx context.Context) error {
+	return e.client.Flush(ctx)
+}
+
+// Shutdown sends all logs buffered by the client. It does not close the
+// client, which may still be used for other logs.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	return e.client.Flush(ctx)
+}
+
+// convertRecord builds a LogTide log from an OpenTelemetry record.
+func convertRecord(r *sdklog.Record) logtide.Log {
+	entry := logtide.Log{
+		Time:    r.Timestamp(),
+		Level:   Level(r.Severity()),
+		Message: r.Body().String(),
+	}
+	if entry.Time.IsZero() {
+		entry.Time = r.ObservedTimestamp()
+	}
+	if r.Body().Kind() == log.KindString {
+		entry.Message = r.Body().AsString()
+	}
+	if traceID := r.TraceID(); traceID.IsValid() {
+		entry.TraceID = traceID.String()
+	}
+	if spanID := r.SpanID(); spanID.IsValid() {
+		entry.SpanID = spanID.String()
+	}
+
+	if n := r.AttributesLen(); n > 0 {
+		entry.Metadata = make(map[string]interface{}, n)
+		r.WalkAttributes(func(kv log.KeyValue) bool {
+			entry.Metadata[kv.Key] = convertValue(kv.Value)
+			return true
+		})
+	}
+	return entry
+}
+
+// convertValue converts an OpenTelemetry log value to its JSON-compatible Go
+// equivalent.
+func convertValue(v log.Value) interface{} {
+	switch v.Kind() {
+	case log.KindBool:
+		return v.AsBool()
+	case log.KindInt64:
+		return v.AsInt64()
+	case log.KindFloat64:
+		return v.AsFloat64()
+	case log.KindString:
+		return v.AsString()
+	case log.KindBytes:
+		return v.AsBytes()
+	case log.KindSlice:
+		values := v.AsSlice()
+		out := make([]interface{}, len(values))
+		for i, elem := range values {
+			out[i] = convertValue(elem)
+		}
+		return out
+	case log.KindMap:
+		kvs := v.AsMap()
+		out := make(map[string]interface{}, len(kvs))
+		for _, kv := range kvs {
+			out[kv.Key] = convertValue(kv.Value)
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// Level maps an OpenTelemetry severity number to a LogTide level. Trace and
+// debug severities map to debug, fatal severities to critical, and an
+// undefined severity to info.
+func Level(severity log.Severity) logtide.LogLevel {
+	switch {
+	case severity >= log.SeverityFatal1:
+		return logtide.LogLevelCritical
+	case severity >= log.SeverityError1:
+		return logtide.LogLevelError
+	case severity >= log.SeverityWarn1:
+		return logtide.LogLevelWarn
+	case severity >= log.SeverityInfo1:
+		return logtide.LogLevelInfo
+	case severity >= log.SeverityTrace1:
+		return logtide.LogLevelDebug
+	default:
+		return logtide.LogLevelInfo
+	}
+}
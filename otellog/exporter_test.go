@@ -0,0 +1,130 @@
+package otellog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	logtide "github.com/logtide-dev/logtide-sdk-go"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestExporterThroughSimpleProcessor(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []logtide.Log
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req logtide.IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		mu.Lock()
+		received = append(received, req.Logs...)
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(logtide.IngestResponse{Received: len(req.Logs)})
+	}))
+	defer server.Close()
+
+	client, err := logtide.New(
+		logtide.WithAPIKey("lp_test_key"),
+		logtide.WithService("test-service"),
+		logtide.WithBaseURL(server.URL),
+		logtide.WithFlushInterval(time.Minute),
+		logtide.WithCaller(true),
+	)
+	if err != nil {
+		t.Fatalf("logtide.New() error = %v", err)
+	}
+	defer client.Close()
+
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithProcessor(sdklog.NewSimpleProcessor(NewExporter(client))),
+	)
+	logger := provider.Logger("test")
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	var record log.Record
+	record.SetTimestamp(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	record.SetSeverity(log.SeverityError2)
+	record.SetBody(log.StringValue("payment failed"))
+	record.AddAttributes(
+		log.String("order_id", "o-42"),
+		log.Int("attempt", 3),
+		log.Map("card", log.Bool("declined", true)),
+	)
+	logger.Emit(ctx, record)
+
+	var plain log.Record
+	plain.SetSeverity(log.SeverityDebug)
+	plain.SetBody(log.StringValue("cache miss"))
+	logger.Emit(context.Background(), plain)
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("ForceFlush() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("server received %d logs, want 2", len(received))
+	}
+
+	got := received[0]
+	if got.Level != logtide.LogLevelError || got.Message != "payment failed" || got.Service != "test-service" {
+		t.Errorf("log = %+v, want an error-level %q from test-service", got, "payment failed")
+	}
+	if !got.Time.Equal(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Time = %v, want the record timestamp", got.Time)
+	}
+	if got.TraceID != traceID.String() || got.SpanID != spanID.String() {
+		t.Errorf("TraceID, SpanID = %q, %q, want %q, %q", got.TraceID, got.SpanID, traceID, spanID)
+	}
+	if got.Metadata["order_id"] != "o-42" || got.Metadata["attempt"] != float64(3) {
+		t.Errorf("Metadata = %v, want order_id and attempt", got.Metadata)
+	}
+	if card, _ := got.Metadata["card"].(map[string]interface{}); card["declined"] != true {
+		t.Errorf("Metadata[card] = %v, want declined", got.Metadata["card"])
+	}
+	// The exporter runs in the processor, so no frame is the emitting code
+	if caller, ok := got.Metadata["caller"]; ok {
+		t.Errorf("Metadata[caller] = %v, want no caller", caller)
+	}
+
+	if received[1].Level != logtide.LogLevelDebug || received[1].Time.IsZero() {
+		t.Errorf("second log = %+v, want a debug log with the observed time", received[1])
+	}
+}
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		severity log.Severity
+		want     logtide.LogLevel
+	}{
+		{log.SeverityUndefined, logtide.LogLevelInfo},
+		{log.SeverityTrace1, logtide.LogLevelDebug},
+		{log.SeverityDebug4, logtide.LogLevelDebug},
+		{log.SeverityInfo1, logtide.LogLevelInfo},
+		{log.SeverityWarn3, logtide.LogLevelWarn},
+		{log.SeverityError1, logtide.LogLevelError},
+		{log.SeverityFatal4, logtide.LogLevelCritical},
+	}
+
+	for _, tt := range tests {
+		if got := Level(tt.severity); got != tt.want {
+			t.Errorf("Level(%v) = %q, want %q", tt.severity, got, tt.want)
+		}
+	}
+}
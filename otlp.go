@@ -0,0 +1,275 @@
+package logtide
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	internalhttp "github.com/logtide-dev/logtide-sdk-go/internal/http"
+)
+
+// otlpLogsPath is the standard OTLP/HTTP logs endpoint, relative to BaseURL.
+const otlpLogsPath = "/v1/logs"
+
+// otlpScopeName is the instrumentation scope reported for every log record.
+const otlpScopeName = "github.com/logtide-dev/logtide-sdk-go"
+
+// otlpRequest is the JSON encoding of an OTLP ExportLogsServiceRequest.
+type otlpRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           otlpAnyValue   `json:"body"`
+	Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+	TraceID        string         `json:"traceId,omitempty"`
+	SpanID         string         `json:"spanId,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// otlpAnyValue holds exactly one of its fields, or none for a null value.
+// Integers are strings, as the OTLP JSON encoding requires for 64-bit values.
+type otlpAnyValue struct {
+	StringValue *string        `json:"stringValue,omitempty"`
+	BoolValue   *bool          `json:"boolValue,omitempty"`
+	IntValue    *string        `json:"intValue,omitempty"`
+	DoubleValue *float64       `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArray     `json:"arrayValue,omitempty"`
+	KvlistValue *otlpKeyValues `json:"kvlistValue,omitempty"`
+}
+
+type otlpArray struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+type otlpKeyValues struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+// otlpResponse is the JSON encoding of an OTLP ExportLogsServiceResponse.
+type otlpResponse struct {
+	PartialSuccess *struct {
+		RejectedLogRecords json.Number `json:"rejectedLogRecords"`
+		ErrorMessage       string      `json:"errorMessage"`
+	} `json:"partialSuccess"`
+}
+
+// otlpSeverities maps each level to its OTLP severity number and text.
+var otlpSeverities = map[LogLevel]struct {
+	number int
+	text   string
+}{
+	LogLevelDebug:    {5, "DEBUG"},
+	LogLevelInfo:     {9, "INFO"},
+	LogLevelWarn:     {13, "WARN"},
+	LogLevelError:    {17, "ERROR"},
+	LogLevelCritical: {21, "FATAL"},
+}
+
+// encodeOTLPRequest returns the OTLP logs JSON body for logs, with one
+// resource per service carrying the service name and batchMetadata as
+// resource attributes. Metadata and tags become log record attributes.
+func encodeOTLPRequest(logs []Log, batchMetadata map[string]interface{}) ([]byte, error) {
+	req := otlpRequest{ResourceLogs: []otlpResourceLogs{}}
+	byService := groupLogs(logs, func(log Log) batchKey { return batchKey{service: log.Service} })
+	for _, group := range byService {
+		attributes := []otlpKeyValue{{Key: "service.name", Value: otlpString(group[0].Service)}}
+		attributes = append(attributes, otlpAttributes(batchMetadata)...)
+
+		records := make([]otlpLogRecord, len(group))
+		for i, log := range group {
+			records[i] = otlpRecord(log)
+		}
+
+		req.ResourceLogs = append(req.ResourceLogs, otlpResourceLogs{
+			Resource: otlpResource{Attributes: attributes},
+			ScopeLogs: []otlpScopeLogs{{
+				Scope:      otlpScope{Name: otlpScopeName, Version: Version},
+				LogRecords: records,
+			}},
+		})
+	}
+	return json.Marshal(&req)
+}
+
+// otlpRecord converts log to an OTLP log record. Attachments become an
+// "attachments" attribute listing their JSON fields.
+func otlpRecord(log Log) otlpLogRecord {
+	severity := otlpSeverities[log.Level]
+	record := otlpLogRecord{
+		TimeUnixNano:   strconv.FormatInt(log.Time.UnixNano(), 10),
+		SeverityNumber: severity.number,
+		SeverityText:   severity.text,
+		Body:           otlpString(log.Message),
+		Attributes:     otlpAttributes(log.Metadata),
+		TraceID:        log.TraceID,
+		SpanID:         log.SpanID,
+	}
+
+	tags := make([]string, 0, len(log.Tags))
+	for key := range log.Tags {
+		tags = append(tags, key)
+	}
+	sort.Strings(tags)
+	for _, key := range tags {
+		record.Attributes = append(record.Attributes, otlpKeyValue{Key: key, Value: otlpString(log.Tags[key])})
+	}
+	if len(log.Attachments) > 0 {
+		record.Attributes = append(record.Attributes, otlpKeyValue{Key: "attachments", Value: otlpJSONValue(log.Attachments)})
+	}
+	return record
+}
+
+// otlpAttributes converts a metadata map to attributes sorted by key.
+func otlpAttributes(metadata map[string]interface{}) []otlpKeyValue {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]otlpKeyValue, len(keys))
+	for i, key := range keys {
+		attributes[i] = otlpKeyValue{Key: key, Value: otlpValue(metadata[key])}
+	}
+	return attributes
+}
+
+// otlpString returns an AnyValue holding s.
+func otlpString(s string) otlpAnyValue {
+	return otlpAnyValue{StringValue: &s}
+}
+
+// otlpValue converts a metadata value to an AnyValue. Values of other types,
+// such as structs, DurationValue, or time.Time, are converted through their
+// JSON encoding.
+func otlpValue(value interface{}) otlpAnyValue {
+	switch v := value.(type) {
+	case nil:
+		return otlpAnyValue{}
+	case string:
+		return otlpString(v)
+	case bool:
+		return otlpAnyValue{BoolValue: &v}
+	case json.Number:
+		if _, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			s := string(v)
+			return otlpAnyValue{IntValue: &s}
+		}
+		f, _ := v.Float64()
+		return otlpAnyValue{DoubleValue: &f}
+	case []interface{}:
+		values := make([]otlpAnyValue, len(v))
+		for i, elem := range v {
+			values[i] = otlpValue(elem)
+		}
+		return otlpAnyValue{ArrayValue: &otlpArray{Values: values}}
+	case map[string]interface{}:
+		return otlpAnyValue{KvlistValue: &otlpKeyValues{Values: otlpAttributes(v)}}
+	case json.Marshaler:
+		return otlpJSONValue(v)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s := strconv.FormatInt(rv.Int(), 10)
+		return otlpAnyValue{IntValue: &s}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		s := strconv.FormatUint(rv.Uint(), 10)
+		return otlpAnyValue{IntValue: &s}
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		return otlpAnyValue{DoubleValue: &f}
+	case reflect.String:
+		return otlpString(rv.String())
+	case reflect.Bool:
+		b := rv.Bool()
+		return otlpAnyValue{BoolValue: &b}
+	default:
+		return otlpJSONValue(value)
+	}
+}
+
+// otlpJSONValue converts value through its JSON encoding, falling back to its
+// fmt representation if it cannot be encoded.
+func otlpJSONValue(value interface{}) otlpAnyValue {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return otlpString(fmt.Sprint(value))
+	}
+
+	var decoded interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&decoded); err != nil {
+		return otlpString(string(data))
+	}
+	return otlpValue(decoded)
+}
+
+// otlpResponse reads the response to an OTLP request of n logs. Records the
+// collector rejected in a partial success are reported to the error handler,
+// and OnAck receives the number accepted, with no server time.
+func (c *Client) otlpResponse(resp *http.Response, n int) error {
+	body, err := internalhttp.ReadResponseBody(resp)
+	if err != nil {
+		return err
+	}
+
+	var otlpResp otlpResponse
+	if strings.TrimSpace(body) != "" {
+		if err := json.Unmarshal([]byte(body), &otlpResp); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	received := n
+	if partial := otlpResp.PartialSuccess; partial != nil {
+		if rejected, _ := partial.RejectedLogRecords.Int64(); rejected > 0 {
+			received -= int(rejected)
+			c.handleError(fmt.Errorf("OTLP endpoint rejected %d of %d logs: %s", rejected, n, partial.ErrorMessage))
+		}
+	}
+
+	if c.config.OnAck != nil {
+		c.config.OnAck(received, time.Time{})
+	}
+	return nil
+}
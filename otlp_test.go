@@ -0,0 +1,167 @@
+package logtide
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncodeOTLPRequest(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	logs := []Log{
+		{
+			Time:     at,
+			Service:  "api",
+			Level:    LogLevelInfo,
+			Message:  "request handled",
+			Metadata: map[string]interface{}{"status": 200, "path": "/users", "cached": true, "duration": Duration(1500 * time.Microsecond)},
+			TraceID:  "0af7651916cd43dd8448eb211c80319c",
+			SpanID:   "b7ad6b7169203331",
+		},
+		{
+			Time:        at.Add(time.Second),
+			Service:     "worker",
+			Level:       LogLevelCritical,
+			Message:     "queue lost",
+			Metadata:    map[string]interface{}{"queues": []interface{}{"a", 1}, "broker": map[string]interface{}{"host": "mq"}, "last": nil},
+			Tags:        map[string]string{"region": "eu"},
+			Attachments: []Attachment{{Ref: "s3://dumps/queue", Size: 4096}},
+		},
+	}
+
+	body, err := encodeOTLPRequest(logs, map[string]interface{}{"host": "node-1"})
+	if err != nil {
+		t.Fatalf("encodeOTLPRequest() error = %v", err)
+	}
+
+	want := `{"resourceLogs":[
+		{
+			"resource":{"attributes":[
+				{"key":"service.name","value":{"stringValue":"api"}},
+				{"key":"host","value":{"stringValue":"node-1"}}
+			]},
+			"scopeLogs":[{
+				"scope":{"name":"github.com/logtide-dev/logtide-sdk-go","version":"` + Version + `"},
+				"logRecords":[{
+					"timeUnixNano":"1704164645000000006",
+					"severityNumber":9,
+					"severityText":"INFO",
+					"body":{"stringValue":"request handled"},
+					"attributes":[
+						{"key":"cached","value":{"boolValue":true}},
+						{"key":"duration","value":{"doubleValue":1.5}},
+						{"key":"path","value":{"stringValue":"/users"}},
+						{"key":"status","value":{"intValue":"200"}}
+					],
+					"traceId":"0af7651916cd43dd8448eb211c80319c",
+					"spanId":"b7ad6b7169203331"
+				}]
+			}]
+		},
+		{
+			"resource":{"attributes":[
+				{"key":"service.name","value":{"stringValue":"worker"}},
+				{"key":"host","value":{"stringValue":"node-1"}}
+			]},
+			"scopeLogs":[{
+				"scope":{"name":"github.com/logtide-dev/logtide-sdk-go","version":"` + Version + `"},
+				"logRecords":[{
+					"timeUnixNano":"1704164646000000006",
+					"severityNumber":21,
+					"severityText":"FATAL",
+					"body":{"stringValue":"queue lost"},
+					"attributes":[
+						{"key":"broker","value":{"kvlistValue":{"values":[{"key":"host","value":{"stringValue":"mq"}}]}}},
+						{"key":"last","value":{}},
+						{"key":"queues","value":{"arrayValue":{"values":[{"stringValue":"a"},{"intValue":"1"}]}}},
+						{"key":"region","value":{"stringValue":"eu"}},
+						{"key":"attachments","value":{"arrayValue":{"values":[{"kvlistValue":{"values":[
+							{"key":"ref","value":{"stringValue":"s3://dumps/queue"}},
+							{"key":"size","value":{"intValue":"4096"}}
+						]}}]}}}
+					]
+				}]
+			}]
+		}
+	]}`
+
+	var got, wantValue interface{}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("encoded body %s is not JSON: %v", body, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("want is not JSON: %v", err)
+	}
+	if !reflect.DeepEqual(got, wantValue) {
+		t.Errorf("encodeOTLPRequest() = %s\nwant %s", body, want)
+	}
+}
+
+func TestClientOTLP(t *testing.T) {
+	var path, apiKey string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, apiKey = r.URL.Path, r.Header.Get("X-API-Key")
+		body, _ = io.ReadAll(r.Body)
+		w.Write([]byte(`{"partialSuccess":{"rejectedLogRecords":"1","errorMessage":"too old"}}`))
+	}))
+	defer server.Close()
+
+	var received int
+	var handled error
+	client, err := New(
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithOTLP(true),
+		WithOnAck(func(n int, serverTime time.Time) { received = n }),
+		WithErrorHandler(func(err error) { handled = err }),
+	)
+	if err != nil {
+		t.Fatalf("New() without an API key error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Warn(ctx, "disk almost full", nil)
+	client.Info(ctx, "stale", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if path != "/v1/logs" {
+		t.Errorf("request path = %q, want /v1/logs", path)
+	}
+	if apiKey != "" {
+		t.Errorf("X-API-Key = %q, want no header without an API key", apiKey)
+	}
+	var req otlpRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("request body %s is not an OTLP request: %v", body, err)
+	}
+	if len(req.ResourceLogs) != 1 || len(req.ResourceLogs[0].ScopeLogs[0].LogRecords) != 2 {
+		t.Fatalf("request body = %s, want one resource with two records", body)
+	}
+	if record := req.ResourceLogs[0].ScopeLogs[0].LogRecords[0]; record.SeverityNumber != 13 || *record.Body.StringValue != "disk almost full" {
+		t.Errorf("first record = %+v, want a WARN record with the message as body", record)
+	}
+	if received != 1 {
+		t.Errorf("OnAck received = %d, want 1 after a partial success rejecting 1 of 2", received)
+	}
+	if handled == nil {
+		t.Error("partial success was not reported to the error handler")
+	}
+}
+
+func TestConfigValidateOTLP(t *testing.T) {
+	_, err := New(WithService("test-service"), WithOTLP(true), WithStreaming(true))
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "otlp" {
+		t.Errorf("New() with OTLP and streaming error = %v, want a ValidationError for otlp", err)
+	}
+}
@@ -0,0 +1,68 @@
+package logtide
+
+import (
+	"os"
+	"time"
+)
+
+// Preset is a bundle of defaults tuned for one kind of environment, applied
+// with WithPreset.
+type Preset int
+
+const (
+	// PresetDev suits local development: small batches sent every second,
+	// error logs flushed as soon as they are logged, and SDK events such as
+	// failed flushes written to stderr as they happen. Flushes still run in
+	// the background; use WithSyncMode to send each log before the call
+	// returns.
+	//
+	//	BatchSize: 10, FlushInterval: 1s, FlushLevel: LogLevelError,
+	//	Debug: os.Stderr
+	PresetDev Preset = iota + 1
+
+	// PresetProd suits long-running production services: large batches sent
+	// every 10 seconds from a bounded in-memory queue that drops new logs
+	// rather than growing without limit when the backend is unavailable.
+	// The SDK has no disk buffer, so logs dropped by the queue or still
+	// buffered when the process dies are lost; use WithDeadLetter to persist
+	// batches the client gives up on.
+	//
+	//	BatchSize: 500, FlushInterval: 10s, MaxQueueSize: 50000,
+	//	Backpressure: BackpressureDrop
+	PresetProd
+
+	// PresetServerless suits short-lived functions that may be frozen right
+	// after handling a request: tiny batches, every log flushed immediately,
+	// and short timeouts so shutdown does not outlive the invocation.
+	//
+	//	BatchSize: 10, FlushInterval: 1s, FlushLevel: LogLevelDebug,
+	//	Timeout: 5s, FlushTimeout: 5s, CloseFlushTimeout: 5s
+	PresetServerless
+)
+
+// WithPreset applies the defaults of preset, listed on each Preset constant.
+// Options applied after WithPreset override individual values. Unknown
+// presets are ignored.
+func WithPreset(preset Preset) Option {
+	return func(c *Config) {
+		switch preset {
+		case PresetDev:
+			c.BatchSize = 10
+			c.FlushInterval = time.Second
+			c.FlushLevel = LogLevelError
+			c.Debug = os.Stderr
+		case PresetProd:
+			c.BatchSize = 500
+			c.FlushInterval = 10 * time.Second
+			c.MaxQueueSize = 50000
+			c.Backpressure = BackpressureDrop
+		case PresetServerless:
+			c.BatchSize = 10
+			c.FlushInterval = time.Second
+			c.FlushLevel = LogLevelDebug
+			c.Timeout = 5 * time.Second
+			c.FlushTimeout = 5 * time.Second
+			c.CloseFlushTimeout = 5 * time.Second
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package logtide
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWithPreset(t *testing.T) {
+	tests := []struct {
+		name   string
+		preset Preset
+		check  func(t *testing.T, c *Config)
+	}{
+		{
+			name:   "dev",
+			preset: PresetDev,
+			check: func(t *testing.T, c *Config) {
+				if c.BatchSize != 10 || c.FlushInterval != time.Second {
+					t.Errorf("BatchSize, FlushInterval = %d, %v, want 10, 1s", c.BatchSize, c.FlushInterval)
+				}
+				if c.FlushLevel != LogLevelError {
+					t.Errorf("FlushLevel = %q, want %q", c.FlushLevel, LogLevelError)
+				}
+				if c.Debug != io.Writer(os.Stderr) {
+					t.Errorf("Debug = %v, want os.Stderr", c.Debug)
+				}
+			},
+		},
+		{
+			name:   "prod",
+			preset: PresetProd,
+			check: func(t *testing.T, c *Config) {
+				if c.BatchSize != 500 || c.FlushInterval != 10*time.Second {
+					t.Errorf("BatchSize, FlushInterval = %d, %v, want 500, 10s", c.BatchSize, c.FlushInterval)
+				}
+				if c.MaxQueueSize != 50000 || c.Backpressure != BackpressureDrop {
+					t.Errorf("MaxQueueSize, Backpressure = %d, %v, want 50000, BackpressureDrop", c.MaxQueueSize, c.Backpressure)
+				}
+			},
+		},
+		{
+			name:   "serverless",
+			preset: PresetServerless,
+			check: func(t *testing.T, c *Config) {
+				if c.BatchSize != 10 || c.FlushInterval != time.Second {
+					t.Errorf("BatchSize, FlushInterval = %d, %v, want 10, 1s", c.BatchSize, c.FlushInterval)
+				}
+				if c.FlushLevel != LogLevelDebug {
+					t.Errorf("FlushLevel = %q, want %q", c.FlushLevel, LogLevelDebug)
+				}
+				if c.Timeout != 5*time.Second || c.FlushTimeout != 5*time.Second || c.CloseFlushTimeout != 5*time.Second {
+					t.Errorf("Timeout, FlushTimeout, CloseFlushTimeout = %v, %v, %v, want 5s, 5s, 5s", c.Timeout, c.FlushTimeout, c.CloseFlushTimeout)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			WithPreset(tt.preset)(c)
+			tt.check(t, c)
+
+			c.APIKey = "lp_test_key"
+			c.Service = "test-service"
+			if err := c.validate(); err != nil {
+				t.Errorf("validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestWithPresetOverriddenByLaterOptions(t *testing.T) {
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithPreset(PresetProd),
+		WithBatchSize(50),
+		WithMaxQueueSize(100),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	cfg := client.Config()
+	if cfg.BatchSize != 50 || cfg.MaxQueueSize != 100 {
+		t.Errorf("BatchSize, MaxQueueSize = %d, %d, want the later options' 50, 100", cfg.BatchSize, cfg.MaxQueueSize)
+	}
+	if cfg.FlushInterval != 10*time.Second {
+		t.Errorf("FlushInterval = %v, want the preset's 10s", cfg.FlushInterval)
+	}
+}
@@ -0,0 +1,46 @@
+package logtide
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket limiting how many logs are accepted per
+// second. It is safe for concurrent use.
+type rateLimiter struct {
+	mu sync.Mutex
+
+	rate  float64 // tokens earned per second
+	burst float64
+
+	tokens     float64
+	lastRefill time.Time
+}
+
+// newRateLimiter creates a rate limiter accepting perSecond logs per second
+// with bursts of up to burst logs. The bucket starts full.
+func newRateLimiter(perSecond float64, burst int) *rateLimiter {
+	b := math.Max(1, float64(burst))
+	return &rateLimiter{
+		rate:       perSecond,
+		burst:      b,
+		tokens:     b,
+		lastRefill: time.Now(),
+	}
+}
+
+// allow takes one token from the bucket, reporting false if none is left.
+func (r *rateLimiter) allow() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.tokens = math.Min(r.burst, r.tokens+now.Sub(r.lastRefill).Seconds()*r.rate)
+	r.lastRefill = now
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
@@ -0,0 +1,38 @@
+package logtide
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// RecoverAndLog recovers a panic and logs it at critical level with the
+// panic value in the "panic" metadata field and the goroutine's stack trace
+// in "stack". It must be deferred directly so that it can recover:
+//
+//	go func() {
+//		defer logtide.RecoverAndLog(client, ctx)
+//		work()
+//	}()
+//
+// Unless the client was created with WithRepanic(false), it then flushes the
+// client so the log is not lost and panics again with the same value.
+func RecoverAndLog(client *Client, ctx context.Context) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	client.Critical(ctx, fmt.Sprintf("panic: %v", r), map[string]interface{}{
+		"panic": fmt.Sprint(r),
+		"stack": string(debug.Stack()),
+	})
+
+	if client.config.Repanic {
+		// The program is likely about to crash, so send the log right away
+		flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), client.config.CloseFlushTimeout)
+		client.Flush(flushCtx)
+		cancel()
+		panic(r)
+	}
+}
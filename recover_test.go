@@ -0,0 +1,82 @@
+package logtide
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRecoverAndLog(t *testing.T) {
+	var sink recordingSink
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+		WithRepanic(false),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	func() {
+		defer RecoverAndLog(client, ctx)
+		panic("index out of range")
+	}()
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := sink.Logs()
+	if len(logs) != 1 {
+		t.Fatalf("received %d logs, want 1", len(logs))
+	}
+	got := logs[0]
+	if got.Level != LogLevelCritical || got.Message != "panic: index out of range" {
+		t.Errorf("log = %s %q, want critical %q", got.Level, got.Message, "panic: index out of range")
+	}
+	if got.Metadata["panic"] != "index out of range" {
+		t.Errorf("Metadata[panic] = %v, want the panic value", got.Metadata["panic"])
+	}
+	if stack, _ := got.Metadata["stack"].(string); !strings.Contains(stack, "TestRecoverAndLog") {
+		t.Errorf("Metadata[stack] = %q, want a stack trace through the test", stack)
+	}
+}
+
+func TestRecoverAndLogRepanics(t *testing.T) {
+	var sink recordingSink
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	var repanicked interface{}
+	func() {
+		defer func() { repanicked = recover() }()
+		defer RecoverAndLog(client, context.Background())
+		panic("fatal state")
+	}()
+
+	if repanicked != "fatal state" {
+		t.Errorf("recovered %v after RecoverAndLog, want the original panic value", repanicked)
+	}
+	// The log was flushed before panicking again
+	if logs := sink.Logs(); len(logs) != 1 || logs[0].Level != LogLevelCritical {
+		t.Errorf("received %+v, want the critical panic log", logs)
+	}
+
+	// Without a panic there is nothing to log
+	func() {
+		defer RecoverAndLog(client, context.Background())
+	}()
+	if err := client.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if got := len(sink.Logs()); got != 1 {
+		t.Errorf("received %d logs, want no log without a panic", got)
+	}
+}
@@ -2,10 +2,14 @@ package logtide
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
 	"net/http"
+	"slices"
+	"sync"
 	"time"
 )
 
@@ -14,6 +18,108 @@ type RetryConfig struct {
 	MaxRetries int
 	MinBackoff time.Duration
 	MaxBackoff time.Duration
+
+	// PerAttemptTimeout bounds each individual attempt. Zero means attempts are
+	// only bounded by the overall context.
+	PerAttemptTimeout time.Duration
+
+	// MaxElapsed stops retrying once the time spent on a request, including
+	// the next backoff, would exceed it, even if attempts remain. Zero means
+	// retries are only limited by MaxRetries.
+	MaxElapsed time.Duration
+
+	// Budget, if set, caps retries across all batches sharing it.
+	Budget *RetryBudget
+
+	// RetryableStatusCodes are response status codes retried in addition to
+	// 429, 500, 502, 503, and 504, such as a gateway's 520.
+	RetryableStatusCodes []int
+
+	// Predicate, if set, decides whether a failed attempt is retried instead
+	// of the default conditions and RetryableStatusCodes. resp is nil when
+	// err is not.
+	Predicate func(resp *http.Response, err error) bool
+
+	// Jitter, if set, returns a value in [0, 1) that scales the random part
+	// of each backoff, so tests can make backoff durations deterministic. It
+	// must be safe for concurrent use. Default: math/rand's global source.
+	Jitter func() float64
+
+	// onRetry, if set, is called before waiting to retry a failed attempt.
+	onRetry func(attempt int, backoff time.Duration, resp *http.Response, err error)
+}
+
+// DeliveryMode is the delivery guarantee a client aims for, set with
+// WithDeliveryMode.
+type DeliveryMode int
+
+const (
+	// AtLeastOnce retries failed batches as configured by the retry options,
+	// so a batch whose response was lost may be stored twice unless the
+	// server deduplicates it by its idempotency key (sent by default). It is
+	// the default.
+	AtLeastOnce DeliveryMode = iota
+
+	// AtMostOnce sends each batch once and never retries it, so no log is
+	// stored twice but a failed batch is lost.
+	AtMostOnce
+)
+
+// RetryBudget is a token bucket that limits retries to a fraction of
+// successful requests, so a sustained outage does not multiply the request
+// volume sent to a struggling backend. It is safe for concurrent use.
+type RetryBudget struct {
+	mu sync.Mutex
+
+	ratio      float64 // tokens earned per successful request
+	minPerSec  float64 // tokens earned per second regardless of traffic
+	maxBalance float64
+
+	balance    float64
+	lastRefill time.Time
+}
+
+// NewRetryBudget creates a retry budget allowing retries up to ratio times the
+// number of successful requests, plus minPerSec retries per second so that
+// low-traffic clients can still retry. Unused budget accumulates up to ten
+// seconds' worth of minPerSec, or 10 retries, whichever is larger.
+func NewRetryBudget(ratio float64, minPerSec int) *RetryBudget {
+	return &RetryBudget{
+		ratio:      ratio,
+		minPerSec:  float64(minPerSec),
+		maxBalance: math.Max(10, 10*float64(minPerSec)),
+		balance:    float64(minPerSec),
+		lastRefill: time.Now(),
+	}
+}
+
+// refill adds the time-based allowance. Callers must hold b.mu.
+func (b *RetryBudget) refill() {
+	now := time.Now()
+	b.balance = math.Min(b.maxBalance, b.balance+now.Sub(b.lastRefill).Seconds()*b.minPerSec)
+	b.lastRefill = now
+}
+
+// recordSuccess credits the budget for a successful request.
+func (b *RetryBudget) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	b.balance = math.Min(b.maxBalance, b.balance+b.ratio)
+}
+
+// tryWithdraw takes one retry from the budget, reporting false if none is left.
+func (b *RetryBudget) tryWithdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if b.balance < 1 {
+		return false
+	}
+	b.balance--
+	return true
 }
 
 // DefaultRetryConfig returns the default retry configuration.
@@ -25,11 +131,20 @@ func DefaultRetryConfig() *RetryConfig {
 	}
 }
 
+// permanentError marks an error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
 // shouldRetry determines if a request should be retried based on the response.
 func shouldRetry(resp *http.Response, err error) bool {
 	// Retry on network errors
 	if err != nil {
-		return true
+		var permanent *permanentError
+		return !errors.As(err, &permanent)
 	}
 
 	// Retry on specific HTTP status codes
@@ -49,6 +164,25 @@ func shouldRetry(resp *http.Response, err error) bool {
 	}
 }
 
+// shouldRetry reports whether an attempt should be retried: as decided by the
+// Predicate if one is set, otherwise on the default conditions of the
+// package-level shouldRetry or one of the configured RetryableStatusCodes.
+// Errors that are never safe to retry, such as a failed request interceptor,
+// are not retried either way.
+func (c *RetryConfig) shouldRetry(resp *http.Response, err error) bool {
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	if c.Predicate != nil {
+		return c.Predicate(resp, err)
+	}
+	if shouldRetry(resp, err) {
+		return true
+	}
+	return err == nil && slices.Contains(c.RetryableStatusCodes, resp.StatusCode)
+}
+
 // calculateBackoff calculates the backoff duration for a retry attempt with exponential backoff and jitter.
 func calculateBackoff(attempt int, config *RetryConfig) time.Duration {
 	// Calculate exponential backoff: min_backoff * 2^attempt
@@ -60,12 +194,39 @@ func calculateBackoff(attempt int, config *RetryConfig) time.Duration {
 	}
 
 	// Add jitter (random value between 0 and 25% of backoff)
-	jitter := rand.Float64() * 0.25 * backoff
+	random := rand.Float64
+	if config.Jitter != nil {
+		random = config.Jitter
+	}
+	jitter := random() * 0.25 * backoff
 	backoff += jitter
 
 	return time.Duration(backoff)
 }
 
+// cancelOnClose releases an attempt context once the response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the attempt context.
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// keepAttemptContext defers cancelling the attempt context until the response
+// body is closed, so the caller can still read it.
+func keepAttemptContext(resp *http.Response, cancel context.CancelFunc) {
+	if resp == nil || resp.Body == nil {
+		cancel()
+		return
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+}
+
 // retryableFunc is a function that can be retried.
 type retryableFunc func(ctx context.Context) (*http.Response, error)
 
@@ -73,20 +234,30 @@ type retryableFunc func(ctx context.Context) (*http.Response, error)
 func withRetry(ctx context.Context, config *RetryConfig, fn retryableFunc) (*http.Response, error) {
 	var resp *http.Response
 	var err error
+	start := time.Now()
 
 	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
 		// Execute the function
-		resp, err = fn(ctx)
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if config.PerAttemptTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, config.PerAttemptTimeout)
+		}
+		resp, err = fn(attemptCtx)
 
 		// Check if we should retry
-		if !shouldRetry(resp, err) {
+		if !config.shouldRetry(resp, err) {
 			// Success or non-retryable error
+			if err == nil && config.Budget != nil {
+				config.Budget.recordSuccess()
+			}
+			keepAttemptContext(resp, cancel)
 			return resp, err
 		}
 
 		// Check if we've exhausted retries
 		if attempt == config.MaxRetries {
 			// Last attempt failed
+			keepAttemptContext(resp, cancel)
 			if err != nil {
 				return nil, fmt.Errorf("max retries exceeded: %w", err)
 			}
@@ -96,6 +267,29 @@ func withRetry(ctx context.Context, config *RetryConfig, fn retryableFunc) (*htt
 		// Calculate backoff
 		backoff := calculateBackoff(attempt, config)
 
+		// Give up if waiting for the next attempt would exceed MaxElapsed
+		if config.MaxElapsed > 0 && time.Since(start)+backoff > config.MaxElapsed {
+			keepAttemptContext(resp, cancel)
+			if err != nil {
+				return nil, fmt.Errorf("max retry time exceeded: %w", err)
+			}
+			return resp, nil
+		}
+
+		// Fail fast when the shared retry budget is spent
+		if config.Budget != nil && !config.Budget.tryWithdraw() {
+			keepAttemptContext(resp, cancel)
+			if err != nil {
+				return nil, fmt.Errorf("retry budget exhausted: %w", err)
+			}
+			return resp, nil
+		}
+		cancel()
+
+		if config.onRetry != nil {
+			config.onRetry(attempt, backoff, resp, err)
+		}
+
 		// Wait before retrying, respecting context cancellation
 		select {
 		case <-time.After(backoff):
@@ -3,6 +3,9 @@ package logtide
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/rand"
+	"net"
 	"net/http"
 	"testing"
 	"time"
@@ -21,6 +24,12 @@ func TestShouldRetry(t *testing.T) {
 			err:        errors.New("network error"),
 			want:       true,
 		},
+		{
+			name:       "permanent error",
+			statusCode: 0,
+			err:        fmt.Errorf("wrapped: %w", &permanentError{err: errors.New("aborted")}),
+			want:       false,
+		},
 		{
 			name:       "429 Too Many Requests",
 			statusCode: 429,
@@ -247,3 +256,266 @@ func TestWithRetry(t *testing.T) {
 		}
 	})
 }
+
+func TestWithRetryPerAttemptTimeout(t *testing.T) {
+	attempts := 0
+	config := &RetryConfig{
+		MaxRetries:        2,
+		MinBackoff:        10 * time.Millisecond,
+		MaxBackoff:        100 * time.Millisecond,
+		PerAttemptTimeout: 50 * time.Millisecond,
+	}
+
+	fn := func(ctx context.Context) (*http.Response, error) {
+		attempts++
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("attempt context has no deadline")
+		}
+		if attempts == 1 {
+			// Slow first attempt exceeds its own deadline
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &http.Response{StatusCode: 200}, nil
+	}
+
+	resp, err := withRetry(context.Background(), config, fn)
+	if err != nil {
+		t.Fatalf("withRetry() error = %v, want nil", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("withRetry() status = %d, want 200", resp.StatusCode)
+	}
+	if attempts != 2 {
+		t.Errorf("withRetry() attempts = %d, want 2", attempts)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	t.Run("throttles retries under sustained failure", func(t *testing.T) {
+		attempts := 0
+		config := &RetryConfig{
+			MaxRetries: 3,
+			MinBackoff: time.Millisecond,
+			MaxBackoff: time.Millisecond,
+			Budget:     NewRetryBudget(0.1, 0),
+		}
+
+		fn := func(ctx context.Context) (*http.Response, error) {
+			attempts++
+			return &http.Response{StatusCode: 503}, nil
+		}
+
+		// Without a budget 20 batches would make 80 attempts
+		for i := 0; i < 20; i++ {
+			withRetry(context.Background(), config, fn)
+		}
+
+		if attempts != 20 {
+			t.Errorf("attempts = %d, want 20 (no retries without successes)", attempts)
+		}
+	})
+
+	t.Run("successes earn retries", func(t *testing.T) {
+		budget := NewRetryBudget(0.5, 0)
+		for i := 0; i < 4; i++ {
+			budget.recordSuccess()
+		}
+
+		allowed := 0
+		for budget.tryWithdraw() {
+			allowed++
+		}
+		if allowed != 2 {
+			t.Errorf("retries allowed after 4 successes at ratio 0.5 = %d, want 2", allowed)
+		}
+	})
+
+	t.Run("minimum rate allows retries", func(t *testing.T) {
+		budget := NewRetryBudget(0, 5)
+		if !budget.tryWithdraw() {
+			t.Error("tryWithdraw() = false, want the per-second minimum available")
+		}
+	})
+}
+
+func TestCalculateBackoffDeterministicJitter(t *testing.T) {
+	config := &RetryConfig{
+		MinBackoff: 1 * time.Second,
+		MaxBackoff: 10 * time.Second,
+		Jitter:     rand.New(rand.NewSource(42)).Float64,
+	}
+
+	want := []time.Duration{
+		1093257090 * time.Nanosecond,
+		2033000248 * time.Nanosecond,
+		4604093851 * time.Nanosecond,
+		8417637406 * time.Nanosecond,
+		10109546146 * time.Nanosecond, // capped at max before jitter
+	}
+	for attempt, want := range want {
+		if got := calculateBackoff(attempt, config); got != want {
+			t.Errorf("calculateBackoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestRetryableStatusCodes(t *testing.T) {
+	config := &RetryConfig{RetryableStatusCodes: []int{409, 520}}
+
+	tests := []struct {
+		statusCode int
+		want       bool
+	}{
+		{520, true},
+		{409, true},
+		{503, true}, // defaults still apply
+		{418, false},
+		{400, false},
+		{200, false},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.statusCode}
+		if got := config.shouldRetry(resp, nil); got != tt.want {
+			t.Errorf("shouldRetry(%d) = %v, want %v", tt.statusCode, got, tt.want)
+		}
+	}
+
+	t.Run("withRetry retries a configured 520", func(t *testing.T) {
+		attempts := 0
+		config := &RetryConfig{
+			MaxRetries:           2,
+			MinBackoff:           time.Millisecond,
+			MaxBackoff:           time.Millisecond,
+			RetryableStatusCodes: []int{520},
+		}
+
+		fn := func(ctx context.Context) (*http.Response, error) {
+			attempts++
+			if attempts == 1 {
+				return &http.Response{StatusCode: 520}, nil
+			}
+			return &http.Response{StatusCode: 200}, nil
+		}
+
+		resp, err := withRetry(context.Background(), config, fn)
+		if err != nil || resp.StatusCode != 200 {
+			t.Errorf("withRetry() = %v, %v, want a 200 after retrying the 520", resp, err)
+		}
+		if attempts != 2 {
+			t.Errorf("withRetry() attempts = %d, want 2", attempts)
+		}
+	})
+
+	t.Run("unconfigured 520 is not retried", func(t *testing.T) {
+		attempts := 0
+		fn := func(ctx context.Context) (*http.Response, error) {
+			attempts++
+			return &http.Response{StatusCode: 520}, nil
+		}
+
+		withRetry(context.Background(), &RetryConfig{MaxRetries: 2, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}, fn)
+		if attempts != 1 {
+			t.Errorf("withRetry() attempts = %d, want 1", attempts)
+		}
+	})
+}
+
+func TestRetryPredicate(t *testing.T) {
+	config := &RetryConfig{
+		MaxRetries:           3,
+		MinBackoff:           time.Millisecond,
+		MaxBackoff:           time.Millisecond,
+		RetryableStatusCodes: []int{520},
+		Predicate: func(resp *http.Response, err error) bool {
+			var dnsErr *net.DNSError
+			if errors.As(err, &dnsErr) {
+				return false
+			}
+			return err != nil || resp.StatusCode == 418
+		},
+	}
+
+	t.Run("refuses DNS errors", func(t *testing.T) {
+		attempts := 0
+		dnsErr := &net.DNSError{Err: "no such host", Name: "api.invalid", IsNotFound: true}
+		fn := func(ctx context.Context) (*http.Response, error) {
+			attempts++
+			return nil, dnsErr
+		}
+
+		_, err := withRetry(context.Background(), config, fn)
+		if !errors.Is(err, dnsErr) {
+			t.Errorf("withRetry() error = %v, want the DNS error", err)
+		}
+		if attempts != 1 {
+			t.Errorf("withRetry() attempts = %d, want 1", attempts)
+		}
+	})
+
+	t.Run("retries other errors", func(t *testing.T) {
+		attempts := 0
+		fn := func(ctx context.Context) (*http.Response, error) {
+			attempts++
+			return nil, errors.New("connection reset")
+		}
+
+		withRetry(context.Background(), config, fn)
+		if attempts != 4 {
+			t.Errorf("withRetry() attempts = %d, want 4", attempts)
+		}
+	})
+
+	t.Run("overrides status code defaults", func(t *testing.T) {
+		tests := []struct {
+			statusCode int
+			want       bool
+		}{
+			{418, true},
+			{503, false},
+			{520, false},
+		}
+
+		for _, tt := range tests {
+			if got := config.shouldRetry(&http.Response{StatusCode: tt.statusCode}, nil); got != tt.want {
+				t.Errorf("shouldRetry(%d) = %v, want %v", tt.statusCode, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("permanent errors are never retried", func(t *testing.T) {
+		if config.shouldRetry(nil, &permanentError{err: errors.New("interceptor failed")}) {
+			t.Error("shouldRetry() = true for a permanent error")
+		}
+	})
+}
+
+func TestWithRetryMaxElapsed(t *testing.T) {
+	attempts := 0
+	config := &RetryConfig{
+		MaxRetries: 10,
+		MinBackoff: 50 * time.Millisecond,
+		MaxBackoff: 50 * time.Millisecond,
+		MaxElapsed: 125 * time.Millisecond,
+		Jitter:     func() float64 { return 0 },
+	}
+
+	fn := func(ctx context.Context) (*http.Response, error) {
+		attempts++
+		return nil, errors.New("network error")
+	}
+
+	start := time.Now()
+	_, err := withRetry(context.Background(), config, fn)
+	if err == nil {
+		t.Fatal("withRetry() error = nil, want an error")
+	}
+	if elapsed := time.Since(start); elapsed > config.MaxElapsed {
+		t.Errorf("withRetry() took %v, want at most MaxElapsed %v", elapsed, config.MaxElapsed)
+	}
+	// Backoffs of 50ms end at 50ms and 100ms; a third would end after 125ms
+	if attempts != 3 {
+		t.Errorf("withRetry() attempts = %d, want 3 of the 11 MaxRetries allows", attempts)
+	}
+}
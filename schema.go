@@ -0,0 +1,138 @@
+package logtide
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// FieldType is the JSON type a metadata value must have under a
+// MetadataSchema.
+type FieldType int
+
+const (
+	// FieldAny accepts a value of any type, including nil.
+	FieldAny FieldType = iota
+
+	// FieldString accepts strings.
+	FieldString
+
+	// FieldNumber accepts integers, floats, and json.Number.
+	FieldNumber
+
+	// FieldBool accepts booleans.
+	FieldBool
+
+	// FieldObject accepts maps and structs.
+	FieldObject
+
+	// FieldArray accepts slices and arrays.
+	FieldArray
+)
+
+// String returns the JSON name of the type, e.g. "string" or "number".
+func (t FieldType) String() string {
+	switch t {
+	case FieldAny:
+		return "any"
+	case FieldString:
+		return "string"
+	case FieldNumber:
+		return "number"
+	case FieldBool:
+		return "boolean"
+	case FieldObject:
+		return "object"
+	case FieldArray:
+		return "array"
+	default:
+		return fmt.Sprintf("FieldType(%d)", int(t))
+	}
+}
+
+// FieldSchema describes one metadata key of a MetadataSchema.
+type FieldSchema struct {
+	// Type is the type the value must have.
+	// Default: FieldAny
+	Type FieldType
+
+	// Required rejects logs whose metadata lacks the key.
+	Required bool
+}
+
+// MetadataSchema is a metadata contract enforced by WithMetadataSchema,
+// keyed by metadata key. Keys it does not list are accepted unchecked:
+//
+//	logtide.MetadataSchema{
+//		"user_id": {Type: logtide.FieldString, Required: true},
+//		"attempt": {Type: logtide.FieldNumber},
+//	}
+type MetadataSchema map[string]FieldSchema
+
+// validate checks that every field has a known type.
+func (s MetadataSchema) validate() error {
+	for key, field := range s {
+		if field.Type < FieldAny || field.Type > FieldArray {
+			return &ValidationError{Field: "metadataSchema." + key, Message: fmt.Sprintf("unknown field type %v", field.Type)}
+		}
+	}
+	return nil
+}
+
+// check returns a ValidationError naming the first metadata key that violates
+// the schema, or nil if metadata conforms.
+func (s MetadataSchema) check(metadata map[string]interface{}) error {
+	for key, field := range s {
+		value, ok := metadata[key]
+		if !ok {
+			if field.Required {
+				return &ValidationError{Field: "metadata." + key, Message: fmt.Sprintf("metadata key %q is required", key)}
+			}
+			continue
+		}
+		if !field.Type.matches(value) {
+			return &ValidationError{
+				Field:   "metadata." + key,
+				Message: fmt.Sprintf("metadata key %q must be of type %v, got %T", key, field.Type, value),
+			}
+		}
+	}
+	return nil
+}
+
+// matches reports whether value has type t.
+func (t FieldType) matches(value interface{}) bool {
+	if t == FieldAny {
+		return true
+	}
+	if _, ok := value.(json.Number); ok {
+		return t == FieldNumber
+	}
+	if value == nil {
+		return false
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return t == FieldString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return t == FieldNumber
+	case reflect.Bool:
+		return t == FieldBool
+	case reflect.Map, reflect.Struct:
+		return t == FieldObject
+	case reflect.Slice, reflect.Array:
+		return t == FieldArray
+	default:
+		return false
+	}
+}
@@ -0,0 +1,122 @@
+package logtide
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMetadataSchemaCheck(t *testing.T) {
+	schema := MetadataSchema{
+		"user_id": {Type: FieldString, Required: true},
+		"attempt": {Type: FieldNumber},
+		"cached":  {Type: FieldBool},
+		"request": {Type: FieldObject},
+		"tags":    {Type: FieldArray},
+		"extra":   {},
+	}
+
+	tests := []struct {
+		name      string
+		metadata  map[string]interface{}
+		wantField string
+	}{
+		{
+			name: "conforming",
+			metadata: map[string]interface{}{
+				"user_id": "u-42",
+				"attempt": 3,
+				"cached":  false,
+				"request": map[string]interface{}{"path": "/"},
+				"tags":    []string{"a", "b"},
+				"extra":   nil,
+				"other":   struct{}{},
+			},
+		},
+		{
+			name:     "json number",
+			metadata: map[string]interface{}{"user_id": "u-42", "attempt": json.Number("3")},
+		},
+		{
+			name:      "missing required key",
+			metadata:  map[string]interface{}{"attempt": 3},
+			wantField: "metadata.user_id",
+		},
+		{
+			name:      "nil metadata",
+			wantField: "metadata.user_id",
+		},
+		{
+			name:      "wrong type",
+			metadata:  map[string]interface{}{"user_id": 42},
+			wantField: "metadata.user_id",
+		},
+		{
+			name:      "nil for typed key",
+			metadata:  map[string]interface{}{"user_id": "u-42", "attempt": nil},
+			wantField: "metadata.attempt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.check(tt.metadata)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("check() error = %v, want nil", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) || verr.Field != tt.wantField {
+				t.Errorf("check() error = %v, want a ValidationError for %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestClientMetadataSchema(t *testing.T) {
+	var sink recordingSink
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+		WithDefaultMetadata(map[string]interface{}{"region": "eu-west-1"}),
+		WithMetadataSchema(MetadataSchema{
+			"region":  {Type: FieldString, Required: true},
+			"user_id": {Type: FieldString, Required: true},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Info(ctx, "conforming", map[string]interface{}{"user_id": "u-42"}); err != nil {
+		t.Errorf("Info() with conforming metadata error = %v", err)
+	}
+	err = client.Info(ctx, "violating", map[string]interface{}{"user_id": 42})
+	if !errors.Is(err, &ValidationError{}) {
+		t.Errorf("Info() with violating metadata error = %v, want a ValidationError", err)
+	}
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := sink.Logs()
+	if len(logs) != 1 || logs[0].Message != "conforming" {
+		t.Errorf("sent %+v, want only the conforming log", logs)
+	}
+}
+
+func TestConfigValidateMetadataSchema(t *testing.T) {
+	_, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithMetadataSchema(MetadataSchema{"user_id": {Type: FieldType(99)}}),
+	)
+	if !errors.Is(err, &ValidationError{}) {
+		t.Errorf("New() error = %v, want a ValidationError", err)
+	}
+}
@@ -0,0 +1,64 @@
+package logtide
+
+import (
+	"context"
+	"sync"
+)
+
+// flushScope tracks the logs added with a ContextWithFlushScope context that
+// are still buffered or being delivered.
+type flushScope struct {
+	mu      sync.Mutex
+	pending int
+	idle    chan struct{} // closed and replaced whenever pending drops to zero
+}
+
+// newFlushScope returns an empty scope.
+func newFlushScope() *flushScope {
+	return &flushScope{idle: make(chan struct{})}
+}
+
+// add counts one more log of the scope as pending.
+func (s *flushScope) add() {
+	s.mu.Lock()
+	s.pending++
+	s.mu.Unlock()
+}
+
+// done marks one log of the scope as delivered or given up on.
+func (s *flushScope) done() {
+	s.mu.Lock()
+	s.pending--
+	if s.pending == 0 {
+		close(s.idle)
+		s.idle = make(chan struct{})
+	}
+	s.mu.Unlock()
+}
+
+// wait blocks until no log of the scope is pending or ctx is done.
+func (s *flushScope) wait(ctx context.Context) error {
+	s.mu.Lock()
+	if s.pending == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	idle := s.idle
+	s.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// releaseScopes marks every scoped log in logs as no longer pending.
+func releaseScopes(logs []Log) {
+	for i := range logs {
+		if logs[i].scope != nil {
+			logs[i].scope.done()
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package logtide
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Sink is a destination for batches of logs. Implementations must be safe for
+// concurrent use.
+type Sink interface {
+	// Send delivers a batch of validated logs.
+	Send(ctx context.Context, logs []Log) error
+}
+
+// httpSink is the default Sink, delivering batches to the LogTide ingest API
+// with retry and circuit breaking.
+type httpSink struct {
+	client *Client
+}
+
+// Send implements Sink.
+func (s *httpSink) Send(ctx context.Context, logs []Log) error {
+	return s.client.sendHTTP(ctx, logs)
+}
+
+// WriterSink is a Sink that writes each log as a line of JSON to an io.Writer.
+// It is useful for local development and tests.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink creates a WriterSink writing newline-delimited JSON to w.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewStdoutSink creates a WriterSink writing to standard output.
+func NewStdoutSink() *WriterSink {
+	return NewWriterSink(os.Stdout)
+}
+
+// Send implements Sink.
+func (s *WriterSink) Send(ctx context.Context, logs []Log) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	enc := json.NewEncoder(s.w)
+	for i := range logs {
+		if err := enc.Encode(&logs[i]); err != nil {
+			return fmt.Errorf("failed to write log: %w", err)
+		}
+	}
+	return nil
+}
+
+// multiSink fans each batch out to several sinks.
+type multiSink struct {
+	sinks []Sink
+}
+
+// MultiSink returns a Sink that sends each batch to all of the given sinks
+// concurrently. A failing sink does not prevent delivery to the others; their
+// errors are joined. Sinks still running when ctx is done are reported with
+// ctx.Err() and not waited for. Sinks share the batch and must not modify it.
+func MultiSink(sinks ...Sink) Sink {
+	return &multiSink{sinks: append([]Sink(nil), sinks...)}
+}
+
+// Send implements Sink.
+func (m *multiSink) Send(ctx context.Context, logs []Log) error {
+	type result struct {
+		index int
+		err   error
+	}
+
+	results := make(chan result, len(m.sinks))
+	for i, sink := range m.sinks {
+		go func(i int, sink Sink) {
+			results <- result{index: i, err: sink.Send(ctx, logs)}
+		}(i, sink)
+	}
+
+	pending := make(map[int]bool, len(m.sinks))
+	for i := range m.sinks {
+		pending[i] = true
+	}
+
+	var errs []error
+	for len(pending) > 0 {
+		select {
+		case r := <-results:
+			delete(pending, r.index)
+			if r.err != nil {
+				errs = append(errs, fmt.Errorf("sink %d: %w", r.index, r.err))
+			}
+		case <-ctx.Done():
+			for i := range m.sinks {
+				if pending[i] {
+					errs = append(errs, fmt.Errorf("sink %d: %w", i, ctx.Err()))
+				}
+			}
+			return errors.Join(errs...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,171 @@
+package logtide
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	logs := []Log{
+		{Time: time.Now(), Service: "test", Level: LogLevelInfo, Message: "first"},
+		{Time: time.Now(), Service: "test", Level: LogLevelError, Message: "second"},
+	}
+
+	if err := sink.Send(context.Background(), logs); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	var got []Log
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var log Log
+		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
+			t.Fatalf("line %q is not a JSON log: %v", scanner.Text(), err)
+		}
+		got = append(got, log)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("wrote %d logs, want 2", len(got))
+	}
+	if got[0].Message != "first" || got[1].Message != "second" {
+		t.Errorf("messages = %q, %q, want %q, %q", got[0].Message, got[1].Message, "first", "second")
+	}
+	if got[1].Level != LogLevelError {
+		t.Errorf("level = %v, want %v", got[1].Level, LogLevelError)
+	}
+}
+
+func TestClientWithSink(t *testing.T) {
+	var buf bytes.Buffer
+
+	// No API key is needed when logs do not go to the API
+	client, err := New(
+		WithService("test-service"),
+		WithSink(NewWriterSink(&buf)),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx := context.Background()
+	client.Info(ctx, "to the writer", map[string]interface{}{"key": "value"})
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var log Log
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &log); err != nil {
+		t.Fatalf("sink output %q is not a JSON log: %v", buf.String(), err)
+	}
+	if log.Message != "to the writer" || log.Service != "test-service" {
+		t.Errorf("log = %+v, want message %q from %q", log, "to the writer", "test-service")
+	}
+	if log.Metadata["key"] != "value" {
+		t.Errorf("metadata key = %v, want %q", log.Metadata["key"], "value")
+	}
+}
+
+// recordingSink is a Sink that records every batch it receives.
+type recordingSink struct {
+	mu      sync.Mutex
+	batches [][]Log
+	err     error
+}
+
+func (s *recordingSink) Send(ctx context.Context, logs []Log) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, logs)
+	return s.err
+}
+
+func (s *recordingSink) Batches() [][]Log {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]Log(nil), s.batches...)
+}
+
+// Logs returns every log received so far, in order.
+func (s *recordingSink) Logs() []Log {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var logs []Log
+	for _, batch := range s.batches {
+		logs = append(logs, batch...)
+	}
+	return logs
+}
+
+func TestMultiSink(t *testing.T) {
+	logs := []Log{
+		{Time: time.Now(), Service: "test", Level: LogLevelInfo, Message: "fan out"},
+	}
+
+	t.Run("sends to every sink", func(t *testing.T) {
+		first, second := &recordingSink{}, &recordingSink{}
+
+		if err := MultiSink(first, second).Send(context.Background(), logs); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+
+		for name, sink := range map[string]*recordingSink{"first": first, "second": second} {
+			batches := sink.Batches()
+			if len(batches) != 1 || len(batches[0]) != 1 || batches[0][0].Message != "fan out" {
+				t.Errorf("%s sink received %v, want the batch", name, batches)
+			}
+		}
+	})
+
+	t.Run("continues past a failing sink", func(t *testing.T) {
+		sinkErr := errors.New("disk full")
+		failing, healthy := &recordingSink{err: sinkErr}, &recordingSink{}
+
+		err := MultiSink(failing, healthy).Send(context.Background(), logs)
+		if !errors.Is(err, sinkErr) {
+			t.Errorf("Send() error = %v, want %v", err, sinkErr)
+		}
+		if len(healthy.Batches()) != 1 {
+			t.Error("healthy sink did not receive the batch")
+		}
+	})
+
+	t.Run("does not wait for a stuck sink past the deadline", func(t *testing.T) {
+		stuck := sinkFunc(func(ctx context.Context, logs []Log) error {
+			time.Sleep(time.Second)
+			return nil
+		})
+		healthy := &recordingSink{}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := MultiSink(stuck, healthy).Send(ctx, logs)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Send() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("Send() took %v, want it bounded by the context", elapsed)
+		}
+		if len(healthy.Batches()) != 1 {
+			t.Error("healthy sink did not receive the batch")
+		}
+	})
+}
+
+// sinkFunc adapts a function to the Sink interface.
+type sinkFunc func(ctx context.Context, logs []Log) error
+
+func (f sinkFunc) Send(ctx context.Context, logs []Log) error {
+	return f(ctx, logs)
+}
@@ -0,0 +1,149 @@
+package logtide
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyWindow is the number of recent flush durations kept for percentiles.
+const latencyWindow = 1024
+
+// Stats is a snapshot of the client's delivery statistics.
+type Stats struct {
+	// Flushes is the number of batches handed to the sink.
+	Flushes int64
+
+	// FlushErrors is the number of batches whose delivery failed.
+	FlushErrors int64
+
+	// Dropped is the number of logs evicted from the ring buffer before they
+	// could be sent.
+	Dropped int64
+
+	// Expired is the number of logs discarded at flush time for being older
+	// than the configured maximum age.
+	Expired int64
+
+	// FlushLatencyP50, FlushLatencyP95, and FlushLatencyP99 are percentiles of
+	// the duration of the most recent flushes (up to 1024).
+	FlushLatencyP50 time.Duration
+	FlushLatencyP95 time.Duration
+	FlushLatencyP99 time.Duration
+
+	// FlushLatencyMax is the longest flush since the client was created.
+	FlushLatencyMax time.Duration
+
+	// LastFlush is when a batch was last delivered successfully, or the zero
+	// time if none has been.
+	LastFlush time.Time
+
+	// QueueWaitAvg and QueueWaitMax are the average and longest time logs
+	// spent buffered before their batch was flushed, since the client was
+	// created. Logs sent in SyncMode are not counted.
+	QueueWaitAvg time.Duration
+	QueueWaitMax time.Duration
+}
+
+// statsRecorder accumulates delivery statistics in bounded memory.
+type statsRecorder struct {
+	mu sync.Mutex
+
+	flushes     int64
+	flushErrors int64
+	expired     int64
+
+	// latencies is a ring buffer of the most recent flush durations.
+	latencies  [latencyWindow]time.Duration
+	maxLatency time.Duration
+
+	// waited is the number of logs whose queue wait is summed in totalWait.
+	waited    int64
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+// recordFlush records the outcome and duration of one flush.
+func (r *statsRecorder) recordFlush(d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.latencies[r.flushes%latencyWindow] = d
+	r.flushes++
+	if err != nil {
+		r.flushErrors++
+	}
+	if d > r.maxLatency {
+		r.maxLatency = d
+	}
+}
+
+// recordQueueWait records how long each buffered log in a batch flushed at
+// now waited since it was added to the batcher.
+func (r *statsRecorder) recordQueueWait(logs []Log, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range logs {
+		if logs[i].enqueued.IsZero() {
+			continue
+		}
+		wait := now.Sub(logs[i].enqueued)
+		r.waited++
+		r.totalWait += wait
+		if wait > r.maxWait {
+			r.maxWait = wait
+		}
+	}
+}
+
+// recordExpired records n logs discarded for their age.
+func (r *statsRecorder) recordExpired(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expired += int64(n)
+}
+
+// snapshot returns the current statistics.
+func (r *statsRecorder) snapshot() Stats {
+	r.mu.Lock()
+	n := int(r.flushes)
+	if n > latencyWindow {
+		n = latencyWindow
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, r.latencies[:n])
+	stats := Stats{
+		Flushes:         r.flushes,
+		FlushErrors:     r.flushErrors,
+		Expired:         r.expired,
+		FlushLatencyMax: r.maxLatency,
+		QueueWaitMax:    r.maxWait,
+	}
+	if r.waited > 0 {
+		stats.QueueWaitAvg = r.totalWait / time.Duration(r.waited)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	stats.FlushLatencyP50 = percentile(sorted, 0.50)
+	stats.FlushLatencyP95 = percentile(sorted, 0.95)
+	stats.FlushLatencyP99 = percentile(sorted, 0.99)
+
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,146 @@
+package logtide
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 0.50, want: 50 * time.Millisecond},
+		{p: 0.95, want: 95 * time.Millisecond},
+		{p: 0.99, want: 99 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("percentile(nil) = %v, want 0", got)
+	}
+}
+
+func TestStatsRecorderBounded(t *testing.T) {
+	var r statsRecorder
+	// Older, slower samples fall out of the percentile window but not the max.
+	// Were they kept, a third of the samples would be slow and P99 would be 1s.
+	for i := 0; i < latencyWindow/2; i++ {
+		r.recordFlush(time.Second, nil)
+	}
+	for i := 0; i < latencyWindow; i++ {
+		r.recordFlush(time.Millisecond, nil)
+	}
+
+	stats := r.snapshot()
+	if want := int64(latencyWindow + latencyWindow/2); stats.Flushes != want {
+		t.Errorf("Flushes = %d, want %d", stats.Flushes, want)
+	}
+	if stats.FlushLatencyP50 != time.Millisecond || stats.FlushLatencyP99 != time.Millisecond {
+		t.Errorf("FlushLatencyP50, FlushLatencyP99 = %v, %v, want 1ms, 1ms", stats.FlushLatencyP50, stats.FlushLatencyP99)
+	}
+	if stats.FlushLatencyMax != time.Second {
+		t.Errorf("FlushLatencyMax = %v, want 1s", stats.FlushLatencyMax)
+	}
+}
+
+func TestClientStats(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every fifth request is slow
+		if atomic.AddInt32(&requests, 1)%5 == 0 {
+			time.Sleep(100 * time.Millisecond)
+		} else {
+			time.Sleep(10 * time.Millisecond)
+		}
+		json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(1*time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		client.Info(ctx, "test message", nil)
+		if err := client.Flush(ctx); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+	}
+
+	stats := client.Stats()
+	if stats.Flushes != 10 {
+		t.Errorf("Flushes = %d, want 10", stats.Flushes)
+	}
+	if stats.FlushErrors != 0 {
+		t.Errorf("FlushErrors = %d, want 0", stats.FlushErrors)
+	}
+	if stats.FlushLatencyP50 < 10*time.Millisecond || stats.FlushLatencyP50 > 90*time.Millisecond {
+		t.Errorf("FlushLatencyP50 = %v, want between 10ms and 90ms", stats.FlushLatencyP50)
+	}
+	if stats.FlushLatencyP99 < 100*time.Millisecond {
+		t.Errorf("FlushLatencyP99 = %v, want >= 100ms", stats.FlushLatencyP99)
+	}
+	if stats.FlushLatencyMax < stats.FlushLatencyP99 {
+		t.Errorf("FlushLatencyMax = %v, want >= p99 %v", stats.FlushLatencyMax, stats.FlushLatencyP99)
+	}
+}
+
+func TestClientStatsQueueWait(t *testing.T) {
+	server := newCaptureServer(t)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(1*time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	if stats := client.Stats(); stats.QueueWaitAvg != 0 || stats.QueueWaitMax != 0 {
+		t.Errorf("queue wait before any flush = %v avg, %v max, want 0", stats.QueueWaitAvg, stats.QueueWaitMax)
+	}
+
+	// One log waits 100ms, the other only briefly
+	ctx := context.Background()
+	client.Info(ctx, "old", nil)
+	time.Sleep(100 * time.Millisecond)
+	client.Info(ctx, "new", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	stats := client.Stats()
+	if stats.QueueWaitMax < 100*time.Millisecond || stats.QueueWaitMax > time.Second {
+		t.Errorf("QueueWaitMax = %v, want about 100ms", stats.QueueWaitMax)
+	}
+	if stats.QueueWaitAvg < 50*time.Millisecond || stats.QueueWaitAvg >= stats.QueueWaitMax {
+		t.Errorf("QueueWaitAvg = %v, want about 50ms and below the max %v", stats.QueueWaitAvg, stats.QueueWaitMax)
+	}
+}
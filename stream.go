@@ -0,0 +1,167 @@
+package logtide
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	internalhttp "github.com/logtide-dev/logtide-sdk-go/internal/http"
+)
+
+// streamPath is the ingest endpoint accepting a continuous NDJSON stream.
+const streamPath = "/api/v1/stream"
+
+// streamSink delivers logs over one long-lived streaming request, writing each
+// batch as newline-delimited JSON frames. A broken stream is reopened with
+// backoff, resending the whole batch, so delivery is at least once.
+type streamSink struct {
+	client *Client
+
+	mu     sync.Mutex
+	conn   *streamConn
+	closed bool
+}
+
+// streamConn is one open streaming request.
+type streamConn struct {
+	pw     *io.PipeWriter
+	cancel context.CancelFunc
+	done   chan struct{} // closed when the request has finished
+	err    error         // result of the request, valid once done is closed
+}
+
+// open starts a streaming request whose body is fed by the returned
+// connection's pipe.
+func (s *streamSink) open() *streamConn {
+	pr, pw := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	conn := &streamConn{pw: pw, cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(conn.done)
+
+		resp, err := s.client.httpClient.Stream(ctx, streamPath, pr)
+		if err == nil {
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				body, _ := internalhttp.ReadResponseBody(resp)
+				err = &HTTPError{
+					StatusCode: resp.StatusCode,
+					Message:    fmt.Sprintf("unexpected status code: %d", resp.StatusCode),
+					Body:       body,
+				}
+			} else {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		}
+		conn.err = err
+
+		// Fail pending and future writes so the next batch reconnects
+		pr.CloseWithError(err)
+	}()
+
+	return conn
+}
+
+// write writes frames to the stream, giving up when ctx is done.
+func (conn *streamConn) write(ctx context.Context, frames []byte) error {
+	stop := context.AfterFunc(ctx, func() {
+		conn.pw.CloseWithError(ctx.Err())
+	})
+	defer stop()
+
+	if _, err := conn.pw.Write(frames); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+	return nil
+}
+
+// Send implements Sink.
+func (s *streamSink) Send(ctx context.Context, logs []Log) error {
+	// Encode up front so a reconnect resends whole frames
+	var buf bytes.Buffer
+	for i := range logs {
+		data, err := encodeLog(&logs[i], s.client.config.TimeFormat)
+		if err != nil {
+			return fmt.Errorf("failed to encode log: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	frames := buf.Bytes()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClientClosed
+	}
+
+	config := s.client.retryConfig
+	for attempt := 0; ; attempt++ {
+		if s.conn == nil {
+			s.conn = s.open()
+		}
+
+		err := s.conn.write(ctx, frames)
+		if err == nil {
+			return nil
+		}
+
+		// Drop the broken stream; the next attempt opens a new one
+		s.conn.cancel()
+		s.conn = nil
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt == config.MaxRetries {
+			return fmt.Errorf("failed to stream batch: %w", err)
+		}
+
+		backoff := calculateBackoff(attempt, config)
+		if config.onRetry != nil {
+			config.onRetry(attempt, backoff, nil, err)
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Close ends the stream and waits up to the client timeout for the server to
+// acknowledge it.
+func (s *streamSink) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	conn := s.conn
+	s.conn = nil
+	s.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	defer conn.cancel()
+
+	// Ending the request body tells the server the stream is complete
+	conn.pw.Close()
+
+	var timeout <-chan time.Time
+	if s.client.config.Timeout > 0 {
+		timeout = time.After(s.client.config.Timeout)
+	}
+	select {
+	case <-conn.done:
+		return conn.err
+	case <-timeout:
+		return ErrTimeout
+	}
+}
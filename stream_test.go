@@ -0,0 +1,236 @@
+package logtide
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// streamServer is a mock stream endpoint recording NDJSON frames per connection.
+type streamServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	streams [][]Log
+}
+
+func newStreamServer(t *testing.T, handle func(s *streamServer, stream int, w http.ResponseWriter, r *http.Request)) *streamServer {
+	t.Helper()
+
+	s := &streamServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != streamPath {
+			t.Errorf("request path = %q, want %q", r.URL.Path, streamPath)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/x-ndjson" {
+			t.Errorf("Content-Type = %q, want application/x-ndjson", got)
+		}
+
+		s.mu.Lock()
+		s.streams = append(s.streams, nil)
+		stream := len(s.streams) - 1
+		s.mu.Unlock()
+
+		if handle != nil {
+			handle(s, stream, w, r)
+			return
+		}
+		s.readFrames(stream, r, -1)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// readFrames records up to limit frames from r, or all of them if limit < 0.
+func (s *streamServer) readFrames(stream int, r *http.Request, limit int) {
+	scanner := bufio.NewScanner(r.Body)
+	for limit != 0 && scanner.Scan() {
+		var log Log
+		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
+			continue
+		}
+		s.mu.Lock()
+		s.streams[stream] = append(s.streams[stream], log)
+		s.mu.Unlock()
+		limit--
+	}
+}
+
+// Streams returns the frames received on each connection so far.
+func (s *streamServer) Streams() [][]Log {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([][]Log, len(s.streams))
+	for i, logs := range s.streams {
+		out[i] = append([]Log(nil), logs...)
+	}
+	return out
+}
+
+// waitFor polls until cond holds or one second has passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestClientStreaming(t *testing.T) {
+	server := newStreamServer(t, nil)
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithStreaming(true),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for batch := 0; batch < 3; batch++ {
+		for i := 0; i < 2; i++ {
+			client.Info(ctx, fmt.Sprintf("batch %d log %d", batch, i), nil)
+		}
+		if err := client.Flush(ctx); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+	}
+
+	// Frames arrive while the stream is still open
+	waitFor(t, func() bool {
+		streams := server.Streams()
+		return len(streams) == 1 && len(streams[0]) == 6
+	})
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	streams := server.Streams()
+	if len(streams) != 1 {
+		t.Fatalf("server saw %d streams, want 1", len(streams))
+	}
+	for i, log := range streams[0] {
+		if want := fmt.Sprintf("batch %d log %d", i/2, i%2); log.Message != want {
+			t.Errorf("frame %d message = %q, want %q", i, log.Message, want)
+		}
+	}
+}
+
+func TestClientStreamingReconnects(t *testing.T) {
+	server := newStreamServer(t, func(s *streamServer, stream int, w http.ResponseWriter, r *http.Request) {
+		if stream == 0 {
+			// Drop the first connection after one frame
+			s.readFrames(stream, r, 1)
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("Hijack() error = %v", err)
+				return
+			}
+			conn.Close()
+			return
+		}
+		s.readFrames(stream, r, -1)
+	})
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithStreaming(true),
+		WithRetry(3, 10*time.Millisecond, 10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "first", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	// Keep logging until the broken stream is noticed and replaced
+	waitFor(t, func() bool {
+		client.Info(ctx, "after drop", nil)
+		client.Flush(ctx)
+		streams := server.Streams()
+		return len(streams) == 2 && len(streams[1]) > 0
+	})
+
+	if got := server.Streams()[0][0].Message; got != "first" {
+		t.Errorf("first stream frame = %q, want first", got)
+	}
+}
+
+func TestClientStreamingTimeFormat(t *testing.T) {
+	frames := make(chan map[string]interface{}, 1)
+	server := newStreamServer(t, func(s *streamServer, stream int, w http.ResponseWriter, r *http.Request) {
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var frame map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &frame); err == nil {
+				frames <- frame
+			}
+		}
+	})
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithStreaming(true),
+		WithTimePrecision(PrecisionMillis),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Emit(ctx, Log{
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC),
+		Level:   LogLevelInfo,
+		Message: "precise",
+	})
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	select {
+	case frame := <-frames:
+		if want := "2024-01-02T03:04:05.123Z"; frame["time"] != want {
+			t.Errorf("frame time = %v, want %q", frame["time"], want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a frame")
+	}
+}
+
+func TestConfigValidateStreamingBatchMetadata(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"batch metadata":          WithBatchMetadata(map[string]interface{}{"producer": "p-1"}),
+		"batch sequence metadata": WithBatchSequenceMetadata(true),
+	} {
+		_, err := New(WithAPIKey("lp_test_key"), WithService("test-service"), WithStreaming(true), opt)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "streaming" {
+			t.Errorf("New() with streaming and %s error = %v, want a ValidationError for streaming", name, err)
+		}
+	}
+}
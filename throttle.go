@@ -0,0 +1,139 @@
+package logtide
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxThrottledKinds bounds the number of error kinds a throttle tracks. Kinds
+// whose window has passed are forgotten when it is reached.
+const maxThrottledKinds = 256
+
+// kindSentinels are the sentinel errors that identify a kind of error on
+// their own.
+var kindSentinels = []error{
+	ErrInvalidAPIKey,
+	ErrCircuitOpen,
+	ErrTimeout,
+	ErrClientClosed,
+	ErrDraining,
+	ErrLogTooLarge,
+	ErrQueueFull,
+	ErrFlushPanicked,
+	context.Canceled,
+	context.DeadlineExceeded,
+	io.EOF,
+	io.ErrUnexpectedEOF,
+}
+
+// errorThrottle calls an error handler at most once per interval for each
+// kind of error, counting the errors it holds back. It is safe for
+// concurrent use.
+type errorThrottle struct {
+	handler  func(error)
+	interval time.Duration
+
+	mu    sync.Mutex
+	kinds map[string]*throttledKind
+}
+
+// throttledKind tracks the current window of one kind of error.
+type throttledKind struct {
+	reported   time.Time // when the handler was last called for this kind
+	suppressed int       // errors held back since then
+}
+
+// newErrorThrottle returns a throttle passing errors on to handler.
+func newErrorThrottle(handler func(error), interval time.Duration) *errorThrottle {
+	return &errorThrottle{
+		handler:  handler,
+		interval: interval,
+		kinds:    make(map[string]*throttledKind),
+	}
+}
+
+// handle passes err on to the handler unless an error of the same kind was
+// passed on less than an interval ago, in which case it is only counted. The
+// first error of a kind after its window is wrapped in a *ThrottledError
+// carrying the count of errors suppressed in between.
+func (t *errorThrottle) handle(err error) {
+	key := errorKind(err)
+	now := time.Now()
+
+	t.mu.Lock()
+	kind, ok := t.kinds[key]
+	if ok && now.Sub(kind.reported) < t.interval {
+		kind.suppressed++
+		t.mu.Unlock()
+		return
+	}
+	if !ok {
+		kind = &throttledKind{}
+		if len(t.kinds) >= maxThrottledKinds {
+			t.forget(now)
+		}
+		// If every tracked kind is still in its window, report without tracking
+		if len(t.kinds) < maxThrottledKinds {
+			t.kinds[key] = kind
+		}
+	}
+	suppressed := kind.suppressed
+	kind.reported = now
+	kind.suppressed = 0
+	t.mu.Unlock()
+
+	if suppressed > 0 {
+		err = &ThrottledError{Err: err, Suppressed: suppressed}
+	}
+	t.handler(err)
+}
+
+// forget drops the kinds whose window has passed at now, along with the
+// count of errors they suppressed. t.mu must be held.
+func (t *errorThrottle) forget(now time.Time) {
+	for key, kind := range t.kinds {
+		if now.Sub(kind.reported) >= t.interval {
+			delete(t.kinds, key)
+		}
+	}
+}
+
+// errorKind groups errors by their innermost cause, so that the same failure
+// wrapped with different context still counts as one kind. A cause is
+// identified by its type, refined by the status code of an *HTTPError, the
+// field of a *ValidationError, or the sentinel it is, but never by its
+// message, which may hold IDs or other unbounded values. Joined errors are
+// grouped by the kinds of all their errors.
+func errorKind(err error) string {
+	switch e := err.(type) {
+	case nil:
+		return "<nil>"
+	case *HTTPError:
+		return fmt.Sprintf("%T %d", e, e.StatusCode)
+	case *ValidationError:
+		return fmt.Sprintf("%T %s", e, e.Field)
+	case interface{ Unwrap() []error }:
+		var kinds []string
+		for _, inner := range e.Unwrap() {
+			kinds = append(kinds, errorKind(inner))
+		}
+		slices.Sort(kinds)
+		return "[" + strings.Join(slices.Compact(kinds), ", ") + "]"
+	}
+
+	// Comparing an error of an uncomparable type would panic
+	if reflect.TypeOf(err).Comparable() && slices.Contains(kindSentinels, err) {
+		return fmt.Sprintf("%T %q", err, err)
+	}
+	if inner := errors.Unwrap(err); inner != nil {
+		return errorKind(inner)
+	}
+	return fmt.Sprintf("%T", err)
+}
@@ -0,0 +1,98 @@
+package logtide
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestErrorThrottle(t *testing.T) {
+	var got []error
+	throttle := newErrorThrottle(func(err error) { got = append(got, err) }, 50*time.Millisecond)
+
+	refused := errors.New("connection refused")
+	for i := 0; i < 5; i++ {
+		throttle.handle(fmt.Errorf("flush %d: %w", i, refused))
+	}
+	throttle.handle(ErrCircuitOpen)
+
+	if len(got) != 2 {
+		t.Fatalf("handler called %d times, want once per kind: %v", len(got), got)
+	}
+	if !errors.Is(got[0], refused) || !errors.Is(got[1], ErrCircuitOpen) {
+		t.Errorf("handler got %v, want the first error of each kind", got)
+	}
+
+	// The next error after the window reports what was suppressed
+	time.Sleep(60 * time.Millisecond)
+	throttle.handle(fmt.Errorf("flush 5: %w", refused))
+
+	if len(got) != 3 {
+		t.Fatalf("handler called %d times, want 3", len(got))
+	}
+	var throttled *ThrottledError
+	if !errors.As(got[2], &throttled) || throttled.Suppressed != 4 {
+		t.Fatalf("handler got %v, want a ThrottledError with 4 suppressed", got[2])
+	}
+	if !errors.Is(got[2], refused) {
+		t.Errorf("ThrottledError does not wrap the latest error: %v", got[2])
+	}
+}
+
+// uncomparableError is an error type that cannot be compared with ==.
+type uncomparableError struct{ ids []int }
+
+func (e uncomparableError) Error() string { return fmt.Sprint("failed ids ", e.ids) }
+
+func TestErrorKind(t *testing.T) {
+	same := [][2]error{
+		{errors.New("order 1 failed"), errors.New("order 2 failed")},
+		{&HTTPError{StatusCode: 503, Message: "request 1"}, fmt.Errorf("flush: %w", &HTTPError{StatusCode: 503, Message: "request 2"})},
+		{fmt.Errorf("batch 1: %w", ErrCircuitOpen), ErrCircuitOpen},
+		{errors.Join(ErrCircuitOpen, ErrTimeout), errors.Join(fmt.Errorf("retry: %w", ErrTimeout), ErrCircuitOpen, ErrTimeout)},
+		{uncomparableError{ids: []int{1}}, uncomparableError{ids: []int{2, 3}}},
+	}
+	for _, pair := range same {
+		if a, b := errorKind(pair[0]), errorKind(pair[1]); a != b {
+			t.Errorf("errorKind(%v) = %q, errorKind(%v) = %q, want the same kind", pair[0], a, pair[1], b)
+		}
+	}
+
+	different := [][2]error{
+		{&HTTPError{StatusCode: 500}, &HTTPError{StatusCode: 503}},
+		{ErrCircuitOpen, ErrTimeout},
+		{ErrCircuitOpen, errors.New("circuit breaker is open")},
+		{errors.Join(ErrCircuitOpen, ErrTimeout), ErrCircuitOpen},
+		{&ValidationError{Field: "level"}, &ValidationError{Field: "service"}},
+	}
+	for _, pair := range different {
+		if a, b := errorKind(pair[0]), errorKind(pair[1]); a == b {
+			t.Errorf("errorKind(%v) = errorKind(%v) = %q, want different kinds", pair[0], pair[1], a)
+		}
+	}
+}
+
+func TestErrorThrottleForgetsKinds(t *testing.T) {
+	reported := 0
+	throttle := newErrorThrottle(func(error) { reported++ }, 20*time.Millisecond)
+
+	for i := 0; i < maxThrottledKinds; i++ {
+		throttle.handle(&HTTPError{StatusCode: 1000 + i})
+	}
+	// Past the limit, new kinds are still reported while the others are in
+	// their window
+	throttle.handle(&HTTPError{StatusCode: 1})
+	throttle.handle(&HTTPError{StatusCode: 1})
+	if reported != maxThrottledKinds+2 || len(throttle.kinds) != maxThrottledKinds {
+		t.Fatalf("reported %d errors tracking %d kinds, want %d reported and %d tracked", reported, len(throttle.kinds), maxThrottledKinds+2, maxThrottledKinds)
+	}
+
+	// Expired kinds make room for new ones
+	time.Sleep(30 * time.Millisecond)
+	throttle.handle(&HTTPError{StatusCode: 2})
+	throttle.handle(&HTTPError{StatusCode: 2})
+	if reported != maxThrottledKinds+3 || len(throttle.kinds) != 1 {
+		t.Errorf("reported %d errors tracking %d kinds, want %d reported and 1 tracked", reported, len(throttle.kinds), maxThrottledKinds+3)
+	}
+}
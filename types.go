@@ -1,6 +1,12 @@
 package logtide
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
 
 // LogLevel represents the severity level of a log entry.
 type LogLevel string
@@ -22,6 +28,83 @@ const (
 	LogLevelCritical LogLevel = "critical"
 )
 
+// severity returns the relative order of a level, higher being more severe.
+// Unknown levels return -1.
+func (l LogLevel) severity() int {
+	switch l {
+	case LogLevelDebug:
+		return 0
+	case LogLevelInfo:
+		return 1
+	case LogLevelWarn:
+		return 2
+	case LogLevelError:
+		return 3
+	case LogLevelCritical:
+		return 4
+	default:
+		return -1
+	}
+}
+
+// levelAliases maps alternative level names registered with
+// RegisterLevelAlias, in lower case, to the level they stand for.
+var (
+	levelAliasesMu sync.RWMutex
+	levelAliases   = map[string]LogLevel{}
+)
+
+// RegisterLevelAlias makes alias, matched case insensitively, stand for level
+// for the whole process: when a LogLevel is decoded from JSON and in the logs
+// of every client. Use WithLevelAlias for aliases accepted by one client only.
+// It is meant to be called from an init function, and panics if alias is
+// empty or level is not one of the LogLevel constants.
+func RegisterLevelAlias(alias string, level LogLevel) {
+	if alias == "" || !validLogLevels[level] {
+		panic(fmt.Sprintf("logtide: invalid level alias %q for level %q", alias, level))
+	}
+	levelAliasesMu.Lock()
+	defer levelAliasesMu.Unlock()
+	levelAliases[strings.ToLower(alias)] = level
+}
+
+// normalizeLevel returns the level a registered alias stands for, and any
+// other level unchanged.
+func normalizeLevel(level LogLevel) LogLevel {
+	if validLogLevels[level] {
+		return level
+	}
+
+	levelAliasesMu.RLock()
+	defer levelAliasesMu.RUnlock()
+	if canonical, ok := levelAliases[strings.ToLower(string(level))]; ok {
+		return canonical
+	}
+	return level
+}
+
+// MarshalJSON implements json.Marshaler, encoding the level in lower case.
+func (l LogLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.ToLower(string(l)))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Levels are matched case
+// insensitively and normalized to lower case, with aliases registered by
+// RegisterLevelAlias replaced by their level; unknown levels are rejected.
+func (l *LogLevel) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("log level must be a string: %w", err)
+	}
+
+	level := normalizeLevel(LogLevel(strings.ToLower(s)))
+	if !validLogLevels[level] {
+		return fmt.Errorf("unknown log level %q", s)
+	}
+	*l = level
+	return nil
+}
+
 // Log represents a single log entry to be sent to LogTide.
 type Log struct {
 	// Time is the timestamp of the log entry. If not set, the current time will be used.
@@ -39,17 +122,53 @@ type Log struct {
 	// Metadata contains additional structured data associated with the log entry (optional).
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 
+	// Tags are short, low-cardinality labels that the backend indexes, such as
+	// region or tier, kept apart from arbitrary Metadata (optional). Keys and
+	// values must be 64 characters or less, and keys must not be empty.
+	Tags map[string]string `json:"tags,omitempty"`
+
 	// TraceID is the W3C trace ID for distributed tracing (optional).
 	TraceID string `json:"trace_id,omitempty"`
 
 	// SpanID is the W3C span ID, must be exactly 16 hex characters if provided (optional).
 	SpanID string `json:"span_id,omitempty"`
+
+	// Attachments reference large content stored elsewhere, such as a request
+	// body uploaded to object storage, instead of inlining it (optional, at
+	// most 10).
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// encoded caches the JSON encoding computed when the log was sized, so it
+	// is not marshaled again when sent. It must be cleared if the log changes.
+	encoded []byte
+
+	// scope is the flush scope of the context the log was added with, if any.
+	scope *flushScope
+
+	// enqueued is when the log was added to the batcher, zero if it never was.
+	enqueued time.Time
+}
+
+// Attachment references content associated with a log that is stored
+// outside LogTide, by URL or content hash.
+type Attachment struct {
+	// Ref locates the content, e.g. "s3://bucket/key" or "sha256:..." (required).
+	Ref string `json:"ref"`
+
+	// ContentType is the MIME type of the content (optional).
+	ContentType string `json:"content_type,omitempty"`
+
+	// Size is the size of the content in bytes (optional).
+	Size int64 `json:"size,omitempty"`
 }
 
 // IngestRequest represents the request payload for batch log ingestion.
 type IngestRequest struct {
 	// Logs is the array of log entries to ingest (1-1000 logs per request).
 	Logs []Log `json:"logs"`
+
+	// BatchMetadata contains attributes that apply to every log in the batch (optional).
+	BatchMetadata map[string]interface{} `json:"batch_metadata,omitempty"`
 }
 
 // IngestResponse represents the response from the log ingestion API.
@@ -60,3 +179,42 @@ type IngestResponse struct {
 	// Timestamp is the server timestamp when the logs were processed.
 	Timestamp string `json:"timestamp"`
 }
+
+// Result describes what happened to a log passed to Client.LogResult.
+type Result int
+
+const (
+	// ResultAccepted means the log was added to the batch for delivery.
+	ResultAccepted Result = iota
+
+	// ResultSampled means the log was discarded by sampling.
+	ResultSampled
+
+	// ResultRateLimited means the log was discarded by the rate limit.
+	ResultRateLimited
+
+	// ResultFiltered means the log was discarded by the filter.
+	ResultFiltered
+
+	// ResultDropped means the log could not be enqueued, e.g. because it was
+	// invalid, the queue was full, or the client was closed.
+	ResultDropped
+)
+
+// String returns the lowercase name of the result.
+func (r Result) String() string {
+	switch r {
+	case ResultAccepted:
+		return "accepted"
+	case ResultSampled:
+		return "sampled"
+	case ResultRateLimited:
+		return "rate_limited"
+	case ResultFiltered:
+		return "filtered"
+	case ResultDropped:
+		return "dropped"
+	default:
+		return fmt.Sprintf("Result(%d)", int(r))
+	}
+}
@@ -0,0 +1,187 @@
+package logtide
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestLogLevelUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    LogLevel
+		wantErr bool
+	}{
+		{name: "lower case", input: `"info"`, want: LogLevelInfo},
+		{name: "upper case", input: `"INFO"`, want: LogLevelInfo},
+		{name: "mixed case", input: `"Critical"`, want: LogLevelCritical},
+		{name: "unknown level", input: `"verbose"`, wantErr: true},
+		{name: "empty level", input: `""`, wantErr: true},
+		{name: "not a string", input: `3`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got LogLevel
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal(%s) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogLevelRoundTrip(t *testing.T) {
+	data, err := json.Marshal(Log{Level: LogLevel("WARN"), Message: "test"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var log Log
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if log.Level != LogLevelWarn {
+		t.Errorf("Level = %q, want %q", log.Level, LogLevelWarn)
+	}
+
+	// An unknown level in a decoded log fails at decode time
+	if err := json.Unmarshal([]byte(`{"level":"loud","message":"test"}`), &log); err == nil {
+		t.Error("Unmarshal() with an unknown level error = nil, want error")
+	}
+}
+
+func TestLevelAlias(t *testing.T) {
+	var sink recordingSink
+	client, err := New(
+		WithService("test-service"),
+		WithSink(&sink),
+		WithLevelAlias("warning", LogLevelWarn),
+		WithLevelAlias("Fatal", LogLevelCritical),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for _, level := range []LogLevel{"warning", "FATAL"} {
+		if err := client.LogContext(ctx, level, "aliased", nil); err != nil {
+			t.Errorf("LogContext(%q) error = %v", level, err)
+		}
+	}
+	if err := client.LogContext(ctx, "verbose", "unregistered", nil); !errors.Is(err, &ValidationError{}) {
+		t.Errorf("LogContext(%q) error = %v, want a ValidationError", "verbose", err)
+	}
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	logs := sink.Logs()
+	if len(logs) != 2 || logs[0].Level != LogLevelWarn || logs[1].Level != LogLevelCritical {
+		t.Fatalf("received %+v, want warn and critical logs", logs)
+	}
+
+	// The aliases belong to the client: other clients and decoding reject them
+	other, err := New(WithService("test-service"), WithSink(&recordingSink{}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer other.Close()
+	if err := other.LogContext(ctx, "warning", "not aliased", nil); !errors.Is(err, &ValidationError{}) {
+		t.Errorf("LogContext(%q) on another client error = %v, want a ValidationError", "warning", err)
+	}
+	var level LogLevel
+	if err := json.Unmarshal([]byte(`"Warning"`), &level); err == nil {
+		t.Errorf("Unmarshal(\"Warning\") = %q, want an error for a client alias", level)
+	}
+}
+
+func TestRegisterLevelAlias(t *testing.T) {
+	RegisterLevelAlias("Notice", LogLevelInfo)
+	t.Cleanup(func() {
+		levelAliasesMu.Lock()
+		defer levelAliasesMu.Unlock()
+		delete(levelAliases, "notice")
+	})
+
+	var level LogLevel
+	if err := json.Unmarshal([]byte(`"NOTICE"`), &level); err != nil || level != LogLevelInfo {
+		t.Errorf("Unmarshal(\"NOTICE\") = %q, %v, want %q", level, err, LogLevelInfo)
+	}
+
+	var sink recordingSink
+	client, err := New(WithService("test-service"), WithSink(&sink))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+	if err := client.LogContext(ctx, "notice", "registered", nil); err != nil {
+		t.Errorf("LogContext(%q) error = %v", "notice", err)
+	}
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if logs := sink.Logs(); len(logs) != 1 || logs[0].Level != LogLevelInfo {
+		t.Errorf("received %+v, want an info log", logs)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterLevelAlias() with an unknown level did not panic")
+		}
+	}()
+	RegisterLevelAlias("verbose", LogLevel("verbose"))
+}
+
+func TestLevelAliasValidation(t *testing.T) {
+	_, err := New(
+		WithService("test-service"),
+		WithSink(&recordingSink{}),
+		WithLevelAlias("notice", LogLevel("notice")),
+	)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "levelAliases" {
+		t.Errorf("New() error = %v, want a levelAliases ValidationError", err)
+	}
+}
+
+func TestLogAttachmentsJSON(t *testing.T) {
+	log := Log{
+		Service: "api",
+		Level:   LogLevelInfo,
+		Message: "request failed",
+		Attachments: []Attachment{
+			{Ref: "s3://bodies/req-123", ContentType: "application/json", Size: 52480},
+			{Ref: "sha256:9f86d081884c7d65"},
+		},
+	}
+
+	data, err := json.Marshal(log)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := `[{"ref":"s3://bodies/req-123","content_type":"application/json","size":52480},{"ref":"sha256:9f86d081884c7d65"}]`
+	if got := string(fields["attachments"]); got != want {
+		t.Errorf("attachments = %s, want %s", got, want)
+	}
+
+	// Logs without attachments do not carry the field
+	data, _ = json.Marshal(Log{Service: "api", Level: LogLevelInfo, Message: "ok"})
+	fields = nil
+	json.Unmarshal(data, &fields)
+	if _, ok := fields["attachments"]; ok {
+		t.Errorf("log without attachments encoded as %s, want no attachments field", data)
+	}
+}
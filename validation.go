@@ -1,8 +1,10 @@
 package logtide
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 var (
@@ -19,6 +21,101 @@ var (
 	}
 )
 
+// maxBatchSize is the most logs the ingest API accepts in one request.
+const maxBatchSize = 1000
+
+// maxServiceLength is the longest service name the ingest API accepts.
+const maxServiceLength = 100
+
+// maxTagLength is the longest tag key or value the ingest API accepts.
+const maxTagLength = 64
+
+// maxAttachments is the most attachments the ingest API accepts on one log.
+const maxAttachments = 10
+
+// SanitizeServiceName turns name into a service name the ingest API accepts:
+// lowercase ASCII letters and digits, with each run of other characters
+// replaced by a single "-", no leading or trailing "-", and at most 100
+// characters. It is meant for WithServiceSanitizer, e.g. "Billing API/v2 "
+// becomes "billing-api-v2". The result is empty if name has no letters or
+// digits.
+func SanitizeServiceName(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+			continue
+		}
+		dash = true
+	}
+
+	sanitized := b.String()
+	if len(sanitized) > maxServiceLength {
+		sanitized = strings.TrimRight(sanitized[:maxServiceLength], "-")
+	}
+	return sanitized
+}
+
+// reservedKeys are the top-level log fields that metadata keys must not shadow.
+var reservedKeys = []string{"time", "service", "level", "message", "trace_id", "span_id", "tags", "attachments"}
+
+// ReservedKeyPolicy controls what happens when a metadata key shadows a
+// top-level log field such as "level" or "trace_id".
+type ReservedKeyPolicy int
+
+const (
+	// PolicyWarn keeps the key and reports a ValidationError to the error handler.
+	PolicyWarn ReservedKeyPolicy = iota
+
+	// PolicyRename prefixes the key with "meta_", e.g. "level" becomes "meta_level".
+	PolicyRename
+
+	// PolicyError rejects the log with a ValidationError.
+	PolicyError
+)
+
+// applyReservedKeyPolicy looks for metadata keys that shadow reserved fields.
+// Under PolicyRename it returns a copy with those keys renamed; otherwise it
+// returns the metadata unchanged and a ValidationError naming the first
+// offending key.
+func applyReservedKeyPolicy(metadata map[string]interface{}, policy ReservedKeyPolicy) (map[string]interface{}, error) {
+	var renamed map[string]interface{}
+
+	for _, key := range reservedKeys {
+		value, ok := metadata[key]
+		if !ok {
+			continue
+		}
+
+		if policy != PolicyRename {
+			return metadata, &ValidationError{
+				Field:   "metadata." + key,
+				Message: fmt.Sprintf("metadata key %q shadows a reserved log field", key),
+			}
+		}
+
+		if renamed == nil {
+			// Copy so the caller's map is never modified
+			renamed = make(map[string]interface{}, len(metadata))
+			for k, v := range metadata {
+				renamed[k] = v
+			}
+		}
+		delete(renamed, key)
+		renamed["meta_"+key] = value
+	}
+
+	if renamed != nil {
+		return renamed, nil
+	}
+	return metadata, nil
+}
+
 // validateLog validates a single log entry according to LogTide's requirements.
 func validateLog(log *Log) error {
 	// Validate service name
@@ -34,7 +131,8 @@ func validateLog(log *Log) error {
 		return &ValidationError{Field: "message", Message: "message is required"}
 	}
 
-	// Validate log level
+	// Validate log level, accepting registered aliases
+	log.Level = normalizeLevel(log.Level)
 	if !validLogLevels[log.Level] {
 		return &ValidationError{
 			Field:   "level",
@@ -50,6 +148,58 @@ func validateLog(log *Log) error {
 		}
 	}
 
+	if err := validateAttachments(log.Attachments); err != nil {
+		return err
+	}
+	return validateTags(log.Tags)
+}
+
+// validateAttachments checks that there are at most maxAttachments, each with
+// a reference and a non-negative size.
+func validateAttachments(attachments []Attachment) error {
+	if len(attachments) > maxAttachments {
+		return &ValidationError{Field: "attachments", Message: fmt.Sprintf("a log must have %d attachments or less", maxAttachments)}
+	}
+	for i, attachment := range attachments {
+		if attachment.Ref == "" {
+			return &ValidationError{Field: fmt.Sprintf("attachments[%d].ref", i), Message: "attachment ref is required"}
+		}
+		if attachment.Size < 0 {
+			return &ValidationError{Field: fmt.Sprintf("attachments[%d].size", i), Message: "attachment size must not be negative"}
+		}
+	}
+	return nil
+}
+
+// validateTags checks that every tag key is non-empty and that keys and
+// values are at most maxTagLength characters.
+func validateTags(tags map[string]string) error {
+	for key, value := range tags {
+		if key == "" {
+			return &ValidationError{Field: "tags", Message: "tag key must not be empty"}
+		}
+		if len(key) > maxTagLength {
+			return &ValidationError{Field: "tags", Message: fmt.Sprintf("tag key %q must be %d characters or less", key, maxTagLength)}
+		}
+		if len(value) > maxTagLength {
+			return &ValidationError{Field: "tags." + key, Message: fmt.Sprintf("tag value must be %d characters or less", maxTagLength)}
+		}
+	}
+	return nil
+}
+
+// validateLogSize checks that a log's JSON encoding fits within maxBytes.
+// It returns an error wrapping ErrLogTooLarge if it does not.
+func validateLogSize(log *Log, maxBytes int) error {
+	data, err := json.Marshal(log)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log: %w", err)
+	}
+	if len(data) > maxBytes {
+		return fmt.Errorf("%w: serialized log is %d bytes, limit is %d", ErrLogTooLarge, len(data), maxBytes)
+	}
+	// Keep the encoding so sending the log does not marshal it again
+	log.encoded = data
 	return nil
 }
 
@@ -58,8 +208,8 @@ func validateBatch(logs []Log) error {
 	if len(logs) == 0 {
 		return &ValidationError{Field: "logs", Message: "at least one log is required"}
 	}
-	if len(logs) > 1000 {
-		return &ValidationError{Field: "logs", Message: "batch size must be 1000 logs or less"}
+	if len(logs) > maxBatchSize {
+		return &ValidationError{Field: "logs", Message: fmt.Sprintf("batch size must be %d logs or less", maxBatchSize)}
 	}
 
 	// Validate each log in the batch
@@ -1,6 +1,8 @@
 package logtide
 
 import (
+	"errors"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -126,6 +128,100 @@ func TestValidateLog(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid tags at the length limit",
+			log: &Log{
+				Time:    time.Now(),
+				Service: "test-service",
+				Level:   LogLevelInfo,
+				Message: "test message",
+				Tags:    map[string]string{strings.Repeat("k", 64): strings.Repeat("v", 64), "empty": ""},
+			},
+			wantErr: false,
+		},
+		{
+			name: "tag key too long",
+			log: &Log{
+				Time:    time.Now(),
+				Service: "test-service",
+				Level:   LogLevelInfo,
+				Message: "test message",
+				Tags:    map[string]string{strings.Repeat("k", 65): "v"},
+			},
+			wantErr: true,
+			errMsg:  "tag key",
+		},
+		{
+			name: "tag value too long",
+			log: &Log{
+				Time:    time.Now(),
+				Service: "test-service",
+				Level:   LogLevelInfo,
+				Message: "test message",
+				Tags:    map[string]string{"region": strings.Repeat("v", 65)},
+			},
+			wantErr: true,
+			errMsg:  "tag value must be 64 characters or less",
+		},
+		{
+			name: "empty tag key",
+			log: &Log{
+				Time:    time.Now(),
+				Service: "test-service",
+				Level:   LogLevelInfo,
+				Message: "test message",
+				Tags:    map[string]string{"": "v"},
+			},
+			wantErr: true,
+			errMsg:  "tag key must not be empty",
+		},
+		{
+			name: "valid attachments",
+			log: &Log{
+				Time:        time.Now(),
+				Service:     "test-service",
+				Level:       LogLevelInfo,
+				Message:     "test message",
+				Attachments: slices.Repeat([]Attachment{{Ref: "s3://bodies/1", ContentType: "application/json", Size: 2048}}, 10),
+			},
+			wantErr: false,
+		},
+		{
+			name: "attachment without ref",
+			log: &Log{
+				Time:        time.Now(),
+				Service:     "test-service",
+				Level:       LogLevelInfo,
+				Message:     "test message",
+				Attachments: []Attachment{{ContentType: "application/json"}},
+			},
+			wantErr: true,
+			errMsg:  "attachment ref is required",
+		},
+		{
+			name: "too many attachments",
+			log: &Log{
+				Time:        time.Now(),
+				Service:     "test-service",
+				Level:       LogLevelInfo,
+				Message:     "test message",
+				Attachments: slices.Repeat([]Attachment{{Ref: "s3://bodies/1"}}, 11),
+			},
+			wantErr: true,
+			errMsg:  "10 attachments or less",
+		},
+		{
+			name: "negative attachment size",
+			log: &Log{
+				Time:        time.Now(),
+				Service:     "test-service",
+				Level:       LogLevelInfo,
+				Message:     "test message",
+				Attachments: []Attachment{{Ref: "s3://bodies/1", Size: -1}},
+			},
+			wantErr: true,
+			errMsg:  "attachment size must not be negative",
+		},
 	}
 
 	for _, tt := range tests {
@@ -207,3 +303,111 @@ func TestValidateBatch(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateLogSize(t *testing.T) {
+	log := &Log{
+		Time:    time.Now(),
+		Service: "test-service",
+		Level:   LogLevelInfo,
+		Message: "small message",
+	}
+
+	if err := validateLogSize(log, 1024); err != nil {
+		t.Errorf("validateLogSize() error = %v, want nil", err)
+	}
+
+	log.Metadata = map[string]interface{}{"body": strings.Repeat("x", 2048)}
+	err := validateLogSize(log, 1024)
+	if !errors.Is(err, ErrLogTooLarge) {
+		t.Errorf("validateLogSize() error = %v, want %v", err, ErrLogTooLarge)
+	}
+}
+
+func TestApplyReservedKeyPolicy(t *testing.T) {
+	t.Run("rename prefixes reserved keys", func(t *testing.T) {
+		metadata := map[string]interface{}{"level": "high", "user": "alice"}
+
+		got, err := applyReservedKeyPolicy(metadata, PolicyRename)
+		if err != nil {
+			t.Fatalf("applyReservedKeyPolicy() error = %v", err)
+		}
+		if got["meta_level"] != "high" {
+			t.Errorf("meta_level = %v, want %q", got["meta_level"], "high")
+		}
+		if _, ok := got["level"]; ok {
+			t.Error("reserved key level was not renamed")
+		}
+		if got["user"] != "alice" {
+			t.Errorf("user = %v, want %q", got["user"], "alice")
+		}
+		if _, ok := metadata["meta_level"]; ok {
+			t.Error("caller's metadata was modified")
+		}
+	})
+
+	t.Run("error rejects reserved keys", func(t *testing.T) {
+		_, err := applyReservedKeyPolicy(map[string]interface{}{"trace_id": "abc"}, PolicyError)
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("applyReservedKeyPolicy() error = %v, want ValidationError", err)
+		}
+		if validationErr.Field != "metadata.trace_id" {
+			t.Errorf("Field = %q, want %q", validationErr.Field, "metadata.trace_id")
+		}
+	})
+
+	t.Run("attachments is reserved", func(t *testing.T) {
+		_, err := applyReservedKeyPolicy(map[string]interface{}{"attachments": "report.pdf"}, PolicyError)
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "metadata.attachments" {
+			t.Errorf("applyReservedKeyPolicy() error = %v, want a ValidationError for metadata.attachments", err)
+		}
+	})
+
+	t.Run("tags is reserved", func(t *testing.T) {
+		got, err := applyReservedKeyPolicy(map[string]interface{}{"tags": []string{"a"}}, PolicyRename)
+		if err != nil {
+			t.Fatalf("applyReservedKeyPolicy() error = %v", err)
+		}
+		if _, ok := got["tags"]; ok || got["meta_tags"] == nil {
+			t.Errorf("applyReservedKeyPolicy() = %v, want tags renamed to meta_tags", got)
+		}
+	})
+
+	t.Run("no reserved keys", func(t *testing.T) {
+		metadata := map[string]interface{}{"user": "alice"}
+
+		got, err := applyReservedKeyPolicy(metadata, PolicyError)
+		if err != nil {
+			t.Errorf("applyReservedKeyPolicy() error = %v, want nil", err)
+		}
+		if len(got) != 1 || got["user"] != "alice" {
+			t.Errorf("applyReservedKeyPolicy() = %v, want metadata unchanged", got)
+		}
+	})
+}
+
+func TestSanitizeServiceName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "already valid", input: "billing-api", want: "billing-api"},
+		{name: "mixed case and separators", input: "Billing API/v2 ", want: "billing-api-v2"},
+		{name: "runs of invalid characters", input: "  __Order  Service__  ", want: "order-service"},
+		{name: "non-ascii", input: "café-ünit", want: "caf-nit"},
+		{name: "nothing valid", input: "/// ", want: ""},
+		{name: "truncated", input: strings.Repeat("a", 99) + "/b", want: strings.Repeat("a", 99)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeServiceName(tt.input); got != tt.want {
+				t.Errorf("SanitizeServiceName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
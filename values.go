@@ -0,0 +1,83 @@
+package logtide
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// DurationValue is a metadata value holding a duration, encoded as a number
+// of milliseconds so that dashboards see the same unit from every service.
+type DurationValue time.Duration
+
+// Duration returns a metadata value for d, encoded as fractional
+// milliseconds, e.g. 1500 for 1.5s:
+//
+//	client.Info(ctx, "request handled", map[string]interface{}{
+//		"duration": logtide.Duration(time.Since(start)),
+//	})
+func Duration(d time.Duration) DurationValue {
+	return DurationValue(d)
+}
+
+// Milliseconds returns the duration as fractional milliseconds.
+func (d DurationValue) Milliseconds() float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// String formats the duration like time.Duration.
+func (d DurationValue) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalJSON encodes the duration as fractional milliseconds.
+func (d DurationValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Milliseconds())
+}
+
+// ByteSize is a metadata value holding a size in bytes, encoded as an object
+// with the exact count and a human-readable form, such as
+// {"bytes":1536,"human":"1.5 KiB"}.
+type ByteSize int64
+
+// Bytes returns a metadata value for a size of n bytes.
+func Bytes(n int64) ByteSize {
+	return ByteSize(n)
+}
+
+// byteUnits are the binary unit suffixes used by ByteSize.String.
+var byteUnits = []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// String formats the size with binary units and at most one decimal, e.g.
+// "512 B", "1.5 KiB", or "2 MiB".
+func (b ByteSize) String() string {
+	n := uint64(b)
+	sign := ""
+	if b < 0 {
+		sign = "-"
+		n = -n // two's complement negation also handles math.MinInt64
+	}
+	if n < 1024 {
+		return sign + strconv.FormatUint(n, 10) + " B"
+	}
+
+	value := float64(n)
+	unit := -1
+	for value >= 1024 && unit < len(byteUnits)-1 {
+		value /= 1024
+		unit++
+	}
+	formatted := strconv.FormatFloat(value, 'f', 1, 64)
+	if len(formatted) > 2 && formatted[len(formatted)-2:] == ".0" {
+		formatted = formatted[:len(formatted)-2]
+	}
+	return sign + formatted + " " + byteUnits[unit]
+}
+
+// MarshalJSON encodes the size as its byte count and human-readable form.
+func (b ByteSize) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Bytes int64  `json:"bytes"`
+		Human string `json:"human"`
+	}{int64(b), b.String()})
+}
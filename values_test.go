@@ -0,0 +1,73 @@
+package logtide
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDurationJSON(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0"},
+		{1500 * time.Microsecond, "1.5"},
+		{2 * time.Second, "2000"},
+		{time.Minute + 250*time.Millisecond, "60250"},
+	}
+
+	for _, tt := range tests {
+		data, err := json.Marshal(Duration(tt.d))
+		if err != nil {
+			t.Fatalf("json.Marshal(Duration(%v)) error = %v", tt.d, err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("json.Marshal(Duration(%v)) = %s, want %s", tt.d, data, tt.want)
+		}
+	}
+}
+
+func TestBytesJSON(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, `{"bytes":0,"human":"0 B"}`},
+		{512, `{"bytes":512,"human":"512 B"}`},
+		{1536, `{"bytes":1536,"human":"1.5 KiB"}`},
+		{2 << 20, `{"bytes":2097152,"human":"2 MiB"}`},
+		{-2048, `{"bytes":-2048,"human":"-2 KiB"}`},
+		{math.MaxInt64, `{"bytes":9223372036854775807,"human":"8 EiB"}`},
+		{math.MinInt64, `{"bytes":-9223372036854775808,"human":"-8 EiB"}`},
+	}
+
+	for _, tt := range tests {
+		data, err := json.Marshal(Bytes(tt.n))
+		if err != nil {
+			t.Fatalf("json.Marshal(Bytes(%d)) error = %v", tt.n, err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("json.Marshal(Bytes(%d)) = %s, want %s", tt.n, data, tt.want)
+		}
+	}
+}
+
+func TestDurationAndBytesInMetadata(t *testing.T) {
+	metadata := map[string]interface{}{
+		"duration": Duration(250 * time.Millisecond),
+		"size":     Bytes(4096),
+	}
+	if clean, changed := sanitizeMetadata(metadata); changed {
+		t.Errorf("sanitizeMetadata() replaced values: %v", clean)
+	}
+
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `{"duration":250,"size":{"bytes":4096,"human":"4 KiB"}}`; string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+}
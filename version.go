@@ -0,0 +1,19 @@
+package logtide
+
+// Version is the version of the LogTide Go SDK.
+const Version = "0.1.0"
+
+// SDKVersion returns the version of the LogTide Go SDK.
+func SDKVersion() string {
+	return Version
+}
+
+// userAgent builds the User-Agent header value, keeping the SDK token after an
+// optional application token.
+func userAgent(app string) string {
+	sdk := "logtide-sdk-go/" + Version
+	if app == "" {
+		return sdk
+	}
+	return app + " " + sdk
+}
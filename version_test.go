@@ -0,0 +1,55 @@
+package logtide
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSDKVersion(t *testing.T) {
+	if Version == "" {
+		t.Fatal("Version is empty")
+	}
+	if SDKVersion() != Version {
+		t.Errorf("SDKVersion() = %q, want %q", SDKVersion(), Version)
+	}
+}
+
+func TestVersionSentWithRequests(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "test message", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	h := <-headers
+	if got, want := h.Get("User-Agent"), "logtide-sdk-go/"+Version; got != want {
+		t.Errorf("User-Agent = %q, want %q", got, want)
+	}
+	if !strings.Contains(h.Get("User-Agent"), Version) {
+		t.Errorf("User-Agent %q does not contain version %q", h.Get("User-Agent"), Version)
+	}
+	if got := h.Get("X-SDK-Version"); got != Version {
+		t.Errorf("X-SDK-Version = %q, want %q", got, Version)
+	}
+}
@@ -0,0 +1,144 @@
+package logtide
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// formattedTime is a timestamp that encodes as a JSON string using layout.
+type formattedTime struct {
+	time   time.Time
+	layout string
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t formattedTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.time.Format(t.layout))
+}
+
+// wireLog is a Log as sent with a custom time format. Its Time field shadows
+// the embedded Log.Time in the JSON encoding.
+type wireLog struct {
+	Log
+	Time formattedTime `json:"time"`
+}
+
+// wireIngestRequest is an IngestRequest whose logs use a custom time format.
+type wireIngestRequest struct {
+	Logs          []wireLog              `json:"logs"`
+	BatchMetadata map[string]interface{} `json:"batch_metadata,omitempty"`
+}
+
+// ingestPayload returns the value to encode for req. With an empty timeFormat
+// it returns req itself, so timestamps use the default time.Time encoding.
+func ingestPayload(req *IngestRequest, timeFormat string) interface{} {
+	if timeFormat == "" {
+		return req
+	}
+
+	logs := make([]wireLog, len(req.Logs))
+	for i, log := range req.Logs {
+		logs[i] = wireLog{
+			Log:  log,
+			Time: formattedTime{time: log.Time, layout: timeFormat},
+		}
+	}
+	return &wireIngestRequest{
+		Logs:          logs,
+		BatchMetadata: req.BatchMetadata,
+	}
+}
+
+// encodeIngestRequest returns the JSON body for req. With the default time
+// format it reuses each log's cached encoding where one exists, which for a
+// 1000-log batch of sized logs cuts allocations by about half compared with
+// marshaling the whole request (see BenchmarkFlushLargeBatch).
+func encodeIngestRequest(req *IngestRequest, timeFormat string) ([]byte, error) {
+	if timeFormat != "" {
+		return json.Marshal(ingestPayload(req, timeFormat))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"logs":[`)
+	for i := range req.Logs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		data := req.Logs[i].encoded
+		if data == nil {
+			var err error
+			if data, err = json.Marshal(&req.Logs[i]); err != nil {
+				return nil, err
+			}
+		}
+		buf.Write(data)
+	}
+	buf.WriteByte(']')
+
+	if len(req.BatchMetadata) > 0 {
+		data, err := json.Marshal(req.BatchMetadata)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(`,"batch_metadata":`)
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// encodeLog returns the JSON encoding of log with timestamps formatted with
+// timeFormat, as it appears in an ingest request or a stream frame. With the
+// default time format it reuses the log's cached encoding where one exists.
+func encodeLog(log *Log, timeFormat string) ([]byte, error) {
+	if timeFormat != "" {
+		return json.Marshal(wireLog{Log: *log, Time: formattedTime{time: log.Time, layout: timeFormat}})
+	}
+	if log.encoded != nil {
+		return log.encoded, nil
+	}
+	return json.Marshal(log)
+}
+
+// splitPayload splits logs into consecutive chunks whose ingest requests are
+// at most maxBytes long. overhead is the size of a request with no logs. Logs
+// too large for any request are left out and reported in oversized. With the
+// default time format, the encoding of each log is cached in logs so sending
+// the chunks does not marshal it again.
+func splitPayload(logs []Log, timeFormat string, overhead, maxBytes int) (chunks [][]Log, oversized []Log, err error) {
+	start, size := 0, overhead
+	for i := range logs {
+		data, err := encodeLog(&logs[i], timeFormat)
+		if err != nil {
+			return nil, nil, err
+		}
+		if timeFormat == "" {
+			logs[i].encoded = data
+		}
+		n := len(data)
+		if overhead+n > maxBytes {
+			// Close the chunk before the oversized log and skip it
+			if start < i {
+				chunks = append(chunks, logs[start:i:i])
+			}
+			oversized = append(oversized, logs[i])
+			start, size = i+1, overhead
+			continue
+		}
+		if start < i {
+			n++ // separating comma
+		}
+		if size+n > maxBytes {
+			chunks = append(chunks, logs[start:i:i])
+			start, size = i, overhead
+			n--
+		}
+		size += n
+	}
+	if start < len(logs) {
+		chunks = append(chunks, logs[start:])
+	}
+	return chunks, oversized, nil
+}
@@ -0,0 +1,413 @@
+package logtide
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIngestPayloadTimeFormat(t *testing.T) {
+	ts := time.Date(2025, 6, 1, 12, 30, 45, 123456789, time.UTC)
+	req := &IngestRequest{
+		Logs: []Log{{Time: ts, Service: "svc", Level: LogLevelInfo, Message: "hello"}},
+	}
+
+	tests := []struct {
+		name   string
+		layout string
+		want   string
+	}{
+		{name: "default encoding", layout: "", want: "2025-06-01T12:30:45.123456789Z"},
+		{name: "RFC 3339", layout: time.RFC3339, want: "2025-06-01T12:30:45Z"},
+		{name: "custom layout", layout: "2006-01-02 15:04:05", want: "2025-06-01 12:30:45"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(ingestPayload(req, tt.layout))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+
+			var decoded struct {
+				Logs []map[string]interface{} `json:"logs"`
+			}
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if got := decoded.Logs[0]["time"]; got != tt.want {
+				t.Errorf("time = %v, want %q", got, tt.want)
+			}
+			if got := decoded.Logs[0]["message"]; got != "hello" {
+				t.Errorf("message = %v, want hello", got)
+			}
+		})
+	}
+}
+
+func TestClientTimeFormat(t *testing.T) {
+	var mu sync.Mutex
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithTimeFormat(time.RFC3339),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	ts := time.Date(2025, 6, 1, 12, 30, 45, 500, time.UTC)
+	if err := client.Emit(ctx, Log{Time: ts, Level: LogLevelInfo, Message: "hello"}); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 {
+		t.Fatalf("received %d requests, want 1", len(bodies))
+	}
+
+	var raw struct {
+		Logs []struct {
+			Time string `json:"time"`
+		} `json:"logs"`
+	}
+	if err := json.Unmarshal(bodies[0], &raw); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if raw.Logs[0].Time != "2025-06-01T12:30:45Z" {
+		t.Errorf("time = %q, want RFC 3339 without fractional seconds", raw.Logs[0].Time)
+	}
+
+	// The payload must still decode as a regular ingest request
+	var req IngestRequest
+	if err := json.NewDecoder(bytes.NewReader(bodies[0])).Decode(&req); err != nil {
+		t.Fatalf("decoding IngestRequest error = %v", err)
+	}
+	if !req.Logs[0].Time.Equal(ts.Truncate(time.Second)) {
+		t.Errorf("decoded Time = %v, want %v", req.Logs[0].Time, ts.Truncate(time.Second))
+	}
+}
+
+func TestEncodeIngestRequest(t *testing.T) {
+	ts := time.Date(2025, 6, 1, 12, 30, 45, 0, time.UTC)
+	sized := Log{Time: ts, Service: "svc", Level: LogLevelInfo, Message: "sized", Metadata: map[string]interface{}{"n": 1}}
+	if err := validateLogSize(&sized, 1024); err != nil {
+		t.Fatalf("validateLogSize() error = %v", err)
+	}
+	if sized.encoded == nil {
+		t.Fatal("validateLogSize() did not cache the encoding")
+	}
+
+	req := &IngestRequest{
+		Logs: []Log{
+			sized,
+			{Time: ts, Service: "svc", Level: LogLevelWarn, Message: "unsized <html>", TraceID: "abc"},
+		},
+		BatchMetadata: map[string]interface{}{"producer": "worker-1"},
+	}
+
+	got, err := encodeIngestRequest(req, "")
+	if err != nil {
+		t.Fatalf("encodeIngestRequest() error = %v", err)
+	}
+	want, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("encodeIngestRequest() = %s\nwant %s", got, want)
+	}
+
+	// Without batch metadata the field is omitted, as with json.Marshal
+	req.BatchMetadata = nil
+	got, _ = encodeIngestRequest(req, "")
+	want, _ = json.Marshal(req)
+	if !bytes.Equal(got, want) {
+		t.Errorf("encodeIngestRequest() = %s\nwant %s", got, want)
+	}
+}
+
+func BenchmarkFlushLargeBatch(b *testing.B) {
+	logs := make([]Log, maxBatchSize)
+	for i := range logs {
+		logs[i] = Log{
+			Time:    time.Now(),
+			Service: "bench-service",
+			Level:   LogLevelInfo,
+			Message: "request completed",
+			Metadata: map[string]interface{}{
+				"path":     "/api/v1/orders",
+				"status":   200,
+				"duration": 12.5,
+				"tags":     []string{"api", "orders"},
+			},
+		}
+	}
+
+	// Both variants size every log first, as WithMaxLogBytes does
+	b.Run("naive", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := range logs {
+				if _, err := json.Marshal(&logs[j]); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if _, err := json.Marshal(&IngestRequest{Logs: logs}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		batch := make([]Log, len(logs))
+		for i := 0; i < b.N; i++ {
+			copy(batch, logs)
+			for j := range batch {
+				if err := validateLogSize(&batch[j], 1<<20); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if _, err := encodeIngestRequest(&IngestRequest{Logs: batch}, ""); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// Splitting sizes every log, as WithMaxPayloadBytes does
+	b.Run("split", func(b *testing.B) {
+		b.ReportAllocs()
+		batch := make([]Log, len(logs))
+		for i := 0; i < b.N; i++ {
+			copy(batch, logs)
+			chunks, _, err := splitPayload(batch, "", 0, 64<<10)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, chunk := range chunks {
+				if _, err := encodeIngestRequest(&IngestRequest{Logs: chunk}, ""); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+func TestClientTimePrecision(t *testing.T) {
+	tests := []struct {
+		name      string
+		precision TimePrecision
+		want      string
+	}{
+		{name: "nanos", precision: PrecisionNanos, want: "2025-06-01T12:30:45.123456789Z"},
+		{name: "millis", precision: PrecisionMillis, want: "2025-06-01T12:30:45.123Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies := make(chan []byte, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bodies <- body
+				json.NewEncoder(w).Encode(IngestResponse{Received: 1})
+			}))
+			defer server.Close()
+
+			client, err := New(
+				WithAPIKey("lp_test_key"),
+				WithService("test-service"),
+				WithBaseURL(server.URL),
+				WithTimePrecision(tt.precision),
+			)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer client.Close()
+
+			ctx := context.Background()
+			ts := time.Date(2025, 6, 1, 12, 30, 45, 123456789, time.UTC)
+			if err := client.Emit(ctx, Log{Time: ts, Level: LogLevelInfo, Message: "hello"}); err != nil {
+				t.Fatalf("Emit() error = %v", err)
+			}
+			if err := client.Flush(ctx); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+
+			var raw struct {
+				Logs []struct {
+					Time string `json:"time"`
+				} `json:"logs"`
+			}
+			if err := json.Unmarshal(<-bodies, &raw); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if raw.Logs[0].Time != tt.want {
+				t.Errorf("time = %q, want %q", raw.Logs[0].Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitPayload(t *testing.T) {
+	ts := time.Date(2025, 6, 1, 12, 30, 45, 0, time.UTC)
+	var logs []Log
+	for i := 0; i < 20; i++ {
+		logs = append(logs, Log{Time: ts, Service: "svc", Level: LogLevelInfo, Message: strings.Repeat("x", 10*i)})
+	}
+	logs[7].Message = strings.Repeat("y", 2000) // too large for any request
+
+	for _, layout := range []string{"", time.RFC3339} {
+		overhead := len(mustEncode(t, &IngestRequest{Logs: []Log{}}, layout))
+		chunks, oversized, err := splitPayload(logs, layout, overhead, 600)
+		if err != nil {
+			t.Fatalf("splitPayload() error = %v", err)
+		}
+		if len(oversized) != 1 || oversized[0].Message != logs[7].Message {
+			t.Errorf("oversized = %d logs, want the large one", len(oversized))
+		}
+
+		var rejoined []Log
+		for _, chunk := range chunks {
+			if body := mustEncode(t, &IngestRequest{Logs: chunk}, layout); len(body) > 600 {
+				t.Errorf("chunk of %d logs encodes to %d bytes, want at most 600", len(chunk), len(body))
+			}
+			rejoined = append(rejoined, chunk...)
+		}
+		want := append(append([]Log(nil), logs[:7]...), logs[8:]...)
+		if !reflect.DeepEqual(rejoined, want) {
+			t.Errorf("chunks do not hold the remaining logs in order")
+		}
+		if len(chunks) < 2 {
+			t.Errorf("got %d chunks, want the logs split", len(chunks))
+		}
+	}
+}
+
+func TestSplitPayloadCachesEncoding(t *testing.T) {
+	newLogs := func() []Log {
+		logs := make([]Log, 50)
+		for i := range logs {
+			logs[i] = Log{Time: time.Now(), Service: "svc", Level: LogLevelInfo, Message: "request completed", Metadata: map[string]interface{}{"n": i}}
+		}
+		return logs
+	}
+
+	// Encoding the chunks reuses the encodings computed while splitting
+	logs := newLogs()
+	chunks, _, err := splitPayload(logs, "", 0, 1<<20)
+	if err != nil || len(chunks) != 1 {
+		t.Fatalf("splitPayload() = %d chunks, %v, want 1 chunk", len(chunks), err)
+	}
+	split := testing.AllocsPerRun(10, func() {
+		encodeIngestRequest(&IngestRequest{Logs: chunks[0]}, "")
+	})
+	unsized := newLogs()
+	fresh := testing.AllocsPerRun(10, func() {
+		encodeIngestRequest(&IngestRequest{Logs: unsized}, "")
+	})
+	if split >= fresh/2 {
+		t.Errorf("encoding split logs takes %.0f allocations, want far fewer than the %.0f of unsized logs", split, fresh)
+	}
+
+	// A custom time format is not cached, as the cache holds default encodings
+	logs = newLogs()
+	if _, _, err := splitPayload(logs, time.RFC3339, 0, 1<<20); err != nil {
+		t.Fatalf("splitPayload() error = %v", err)
+	}
+	for i := range logs {
+		if logs[i].encoded != nil {
+			t.Fatalf("log %d has a cached encoding after splitting with a custom time format", i)
+		}
+	}
+}
+
+// mustEncode returns the ingest request body for req.
+func mustEncode(t *testing.T, req *IngestRequest, timeFormat string) []byte {
+	t.Helper()
+	body, err := encodeIngestRequest(req, timeFormat)
+	if err != nil {
+		t.Fatalf("encodeIngestRequest() error = %v", err)
+	}
+	return body
+}
+
+func TestClientMaxPayloadBytes(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req IngestRequest
+		json.Unmarshal(body, &req)
+
+		mu.Lock()
+		sizes = append(sizes, len(body))
+		received += len(req.Logs)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(IngestResponse{Received: len(req.Logs)})
+	}))
+	defer server.Close()
+
+	const limit = 4096
+	client, err := New(
+		WithAPIKey("lp_test_key"),
+		WithService("test-service"),
+		WithBaseURL(server.URL),
+		WithFlushInterval(time.Minute),
+		WithBatchMetadata(map[string]interface{}{"producer": "worker-1"}),
+		WithBatchSequenceMetadata(true),
+		WithMaxPayloadBytes(limit),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 30; i++ {
+		client.Info(ctx, strings.Repeat("large log ", 50), map[string]interface{}{"i": i})
+	}
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if received != 30 {
+		t.Errorf("received %d logs, want 30", received)
+	}
+	if len(sizes) < 2 {
+		t.Errorf("sent %d requests, want the batch split", len(sizes))
+	}
+	for _, size := range sizes {
+		if size > limit {
+			t.Errorf("request body is %d bytes, want at most %d", size, limit)
+		}
+	}
+}
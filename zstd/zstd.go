@@ -0,0 +1,25 @@
+// Package zstd registers zstd request compression with the LogTide SDK. It
+// is a separate module so the SDK itself does not depend on
+// github.com/klauspost/compress. Import it for its side effect:
+//
+//	import _ "github.com/logtide-dev/logtide-sdk-go/zstd"
+//
+//	client, err := logtide.New(
+//		logtide.WithAPIKey("lp_your_api_key"),
+//		logtide.WithService("my-service"),
+//		logtide.WithCompression(logtide.CompressionZstd),
+//	)
+package zstd
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	logtide "github.com/logtide-dev/logtide-sdk-go"
+)
+
+func init() {
+	logtide.RegisterCompressor(logtide.CompressionZstd, func(w io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(w)
+	})
+}
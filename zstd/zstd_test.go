@@ -0,0 +1,75 @@
+package zstd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	logtide "github.com/logtide-dev/logtide-sdk-go"
+)
+
+func TestZstdRoundTrip(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		encoding string
+		received []logtide.Log
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zr, err := zstd.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer zr.Close()
+
+		var req logtide.IngestRequest
+		if err := json.NewDecoder(zr).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		encoding = r.Header.Get("Content-Encoding")
+		received = append(received, req.Logs...)
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(logtide.IngestResponse{Received: len(req.Logs), Timestamp: time.Now().Format(time.RFC3339)})
+	}))
+	defer server.Close()
+
+	client, err := logtide.New(
+		logtide.WithAPIKey("lp_test_key"),
+		logtide.WithService("test-service"),
+		logtide.WithBaseURL(server.URL),
+		logtide.WithCompression(logtide.CompressionZstd),
+		logtide.WithFlushInterval(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("logtide.New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Info(ctx, "first", map[string]interface{}{"attempt": "1"})
+	client.Error(ctx, "second", nil)
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if encoding != "zstd" {
+		t.Errorf("Content-Encoding = %q, want %q", encoding, "zstd")
+	}
+	if len(received) != 2 || received[0].Message != "first" || received[1].Message != "second" {
+		t.Fatalf("received %+v, want the two logs", received)
+	}
+	if received[0].Metadata["attempt"] != "1" {
+		t.Errorf("Metadata = %v, want attempt", received[0].Metadata)
+	}
+}